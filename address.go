@@ -0,0 +1,80 @@
+package miningcore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidAddress is wrapped by the errors of ValidateAddress.
+var ErrInvalidAddress = errors.New("miningcore: invalid address")
+
+const (
+	base58Chars  = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	bech32Chars  = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	hexChars     = "0123456789abcdefABCDEF"
+	alphanumeric = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// ValidateAddress does a basic well-formedness check of a miner address for the coin family,
+// to reject obviously wrong input before sending it to the API. The check is conservative: it only looks
+// at the length, prefix and alphabet of the address and doesn't verify checksums, so an address that passes
+// may still be invalid. Addresses of unknown families always pass.
+func ValidateAddress(family CoinFamily, address string) error {
+	if !family.Known() {
+		return nil
+	}
+	if address == "" {
+		return fmt.Errorf("%w: address is empty", ErrInvalidAddress)
+	}
+	if strings.IndexFunc(address, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("%w: address contains whitespace", ErrInvalidAddress)
+	}
+
+	switch {
+	case family.Is(FamilyEthereum):
+		if len(address) != 42 || !strings.HasPrefix(strings.ToLower(address), "0x") || !onlyChars(address[2:], hexChars) {
+			return fmt.Errorf("%w: %s address must be 0x followed by 40 hex characters", ErrInvalidAddress, family)
+		}
+	case family.Is(FamilyBitcoin), family.Is(FamilyEquihash):
+		// base58 addresses, bech32 addresses of any case, zcash shielded addresses
+		// and cashaddr addresses, which may carry a network prefix like bitcoincash:
+		addr := address
+		if i := strings.IndexByte(addr, ':'); i > 0 && onlyChars(addr[:i], alphanumeric) {
+			addr = addr[i+1:]
+		}
+		if len(addr) < 20 || len(addr) > 100 || !onlyChars(addr, alphanumeric) {
+			return fmt.Errorf("%w: %s address must be 20 to 100 letters and digits, optionally after a prefix like bitcoincash:", ErrInvalidAddress, family)
+		}
+	case family.Is(FamilyCryptonote), family.Is(FamilyConceal):
+		// standard, sub and integrated addresses
+		if len(address) < 90 || len(address) > 140 || !onlyChars(address, base58Chars) {
+			return fmt.Errorf("%w: %s address must be 90 to 140 base58 characters", ErrInvalidAddress, family)
+		}
+	case family.Is(FamilyErgo):
+		if len(address) < 30 || !onlyChars(address, base58Chars) {
+			return fmt.Errorf("%w: %s address must be at least 30 base58 characters", ErrInvalidAddress, family)
+		}
+	case family.Is(FamilyBeam):
+		if !onlyChars(address, alphanumeric) {
+			return fmt.Errorf("%w: %s address must only contain letters and digits", ErrInvalidAddress, family)
+		}
+	case family.Is(FamilyKaspa):
+		i := strings.LastIndexByte(address, ':')
+		if i <= 0 || !strings.HasPrefix(address, "kaspa") || len(address)-i-1 < 60 || !onlyChars(address[i+1:], bech32Chars) {
+			return fmt.Errorf("%w: %s address must be a network prefix like kaspa: followed by at least 60 bech32 characters", ErrInvalidAddress, family)
+		}
+	}
+	return nil
+}
+
+// onlyChars reports whether s only consists of characters of chars.
+func onlyChars(s, chars string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune(chars, r) {
+			return false
+		}
+	}
+	return true
+}
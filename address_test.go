@@ -0,0 +1,75 @@
+package miningcore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAddress(t *testing.T) {
+	const (
+		monero = "44AFFq5kSiGBoZ4NMDwYtN18obc8AemS33DBLWs3H7otXft3XjrpDtQGv7SqSsaBYBb98uNbr2VBBEt7f2wfn3RVGQBEP3A"
+		kaspa  = "kaspa:qrelgny7sr3vahq69yykxx36m65gvmhryxrlwngfzgu8xkdslum2yxjp3ap8m"
+	)
+	tests := []struct {
+		family  CoinFamily
+		address string
+		valid   bool
+	}{
+		{FamilyEthereum, testMiner, true},
+		{FamilyEthereum, strings.ToLower(testMiner), true},
+		{"Ethereum", "0X000000000000000000000000000000000000DEAD", true},
+		{FamilyEthereum, "0x123", false},
+		{FamilyEthereum, "00" + testMiner[2:], false},
+		{FamilyEthereum, "0x000000000000000000000000000000000000dEaG", false},
+		{FamilyEthereum, testMiner + "0", false},
+		{FamilyEthereum, "", false},
+
+		{FamilyBitcoin, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", true},
+		{FamilyBitcoin, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", true},
+		{FamilyBitcoin, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", true},
+		{FamilyBitcoin, "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", true},
+		{FamilyBitcoin, "qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", true},
+		{FamilyBitcoin, "bitcoincash:qpm2q", false},
+		{FamilyBitcoin, ":qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", false},
+		{FamilyBitcoin, "abc", false},
+		{FamilyBitcoin, "1A1zP1eP5QGefi2DMPTfTL5SLmv7Divf-a", false},
+		{FamilyBitcoin, " 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", false},
+		{FamilyEquihash, "t1Rv4exT7bqhZqi2j7xz8bUHDMxwosrjADU", true},
+		{FamilyEquihash, "t1Rv4exT7bqhZqi2j7xz8bUHDMxwosrjADU.rig1", false},
+
+		{FamilyCryptonote, monero, true},
+		{FamilyConceal, monero, true},
+		{FamilyCryptonote, monero[:60], false},
+		{FamilyCryptonote, monero[:94] + "0", false},
+
+		{FamilyErgo, "9fRusAarL1KkrWQVsxSRVYnvWxaAT2A96cKtNn9tvPh5XUyCisr", true},
+		{FamilyErgo, "9fRusAarL1Kkr", false},
+		{FamilyErgo, "9fRusAarL1KkrWQVsxSRVYnvWxaAT2A96cKtNn9tvPh5XUyCisI", false},
+
+		{FamilyBeam, "1f0b5e6c4a7ebb3d2c6e1a8e0c57d2635b24c8f1b7c9d5e0a7c4c8b8d2a0f7e3f1", true},
+		{FamilyBeam, "beam-address!", false},
+
+		{FamilyKaspa, kaspa, true},
+		{FamilyKaspa, "kaspatest:" + kaspa[6:], true},
+		{FamilyKaspa, kaspa[6:], false},
+		{FamilyKaspa, strings.ToUpper(kaspa), false},
+		{FamilyKaspa, "kaspa:qrelgny7", false},
+
+		// unknown families always pass
+		{"newfamily", "anything goes", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		err := ValidateAddress(tt.family, tt.address)
+		if tt.valid {
+			assert.NoError(t, err, "%s %q", tt.family, tt.address)
+			continue
+		}
+		assert.ErrorIs(t, err, ErrInvalidAddress, "%s %q", tt.family, tt.address)
+	}
+
+	err := ValidateAddress(FamilyEthereum, "0x123")
+	assert.EqualError(t, err, "miningcore: invalid address: ethereum address must be 0x followed by 40 hex characters")
+}
@@ -0,0 +1,52 @@
+package miningcore
+
+import (
+	"context"
+	"net/http"
+)
+
+// BalanceAdjustment is a change of the balance of a miner made through the admin API.
+type BalanceAdjustment struct {
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount"`
+	Usage   string  `json:"usage"`
+}
+
+// BalanceResult is the outcome of a single BalanceAdjustment.
+type BalanceResult struct {
+	Adjustment *BalanceAdjustment
+	StatusCode int
+	Err        error
+}
+
+type addBalanceReq struct {
+	PoolID string `json:"poolId"`
+	BalanceAdjustment
+}
+
+// AddBalance adds amount to the balance of a miner, a negative amount subtracts from it.
+// Usage is recorded as reason of the balance change. The request requires access to the admin API and is never retried.
+func (c *Client) AddBalance(ctx context.Context, id string, adj *BalanceAdjustment) (int, error) {
+	req := addBalanceReq{PoolID: id, BalanceAdjustment: *adj}
+	req.Address = c.address(req.Address)
+	return c.doRequest(withoutRetry(ctx), "/api/admin/addbalance", http.MethodPost, nil, req)
+}
+
+// AddBalances applies the adjustments one after another, since the admin API has no batch endpoint,
+// and returns a result for every adjustment that was attempted.
+// If stopOnError is true, it stops at the first failed adjustment and returns its error.
+// Otherwise all adjustments are attempted and failures are only reported in the results.
+func (c *Client) AddBalances(ctx context.Context, id string, adjustments []*BalanceAdjustment, stopOnError bool) ([]*BalanceResult, error) {
+	results := make([]*BalanceResult, 0, len(adjustments))
+	for _, adj := range adjustments {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		s, err := c.AddBalance(ctx, id, adj)
+		results = append(results, &BalanceResult{Adjustment: adj, StatusCode: s, Err: err})
+		if err != nil && stopOnError {
+			return results, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,65 @@
+package miningcore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddBalances(t *testing.T) {
+	var applied []addBalanceReq
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/admin/addbalance", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		var req addBalanceReq
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Address == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		applied = append(applied, req)
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+	adjustments := []*BalanceAdjustment{
+		{Address: "a", Amount: 1.5, Usage: "correction"},
+		{Address: "bad", Amount: 1},
+		{Address: "b", Amount: -0.5, Usage: "correction"},
+	}
+
+	results, err := client.AddBalances(context.Background(), "eth", adjustments, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(results))
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, http.StatusOK, results[0].StatusCode)
+	assert.Error(t, results[1].Err)
+	assert.Equal(t, http.StatusBadRequest, results[1].StatusCode)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, 2, len(applied))
+	assert.Equal(t, "eth", applied[0].PoolID)
+	assert.Equal(t, 1.5, applied[0].Amount)
+	assert.Equal(t, -0.5, applied[1].Amount)
+
+	applied = nil
+	results, err = client.AddBalances(context.Background(), "eth", adjustments, true)
+	assert.Error(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, 1, len(applied))
+}
+
+func TestAddBalanceNotRetried(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, WithRetry(5, 0)).AddBalance(context.Background(), "eth", &BalanceAdjustment{Address: "a", Amount: 1})
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+}
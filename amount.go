@@ -0,0 +1,40 @@
+package miningcore
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// ToWholeCoins converts an amount in the smallest unit of a coin, such as satoshis or wei, to whole coins
+// by dividing it by 10^decimals. The amount is returned unchanged if decimals is not positive.
+func (a Amount) ToWholeCoins(decimals int) Amount {
+	if decimals <= 0 {
+		return a
+	}
+	return a / Amount(math.Pow10(decimals))
+}
+
+// FromBaseUnits converts an integer amount in the smallest unit of a coin to whole coins, see Amount.ToWholeCoins.
+// The amount is a decimal string, so amounts in wei that exceed the range of int64 are converted exactly
+// up to the precision of Amount.
+func FromBaseUnits(units string, decimals int) (Amount, error) {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(units), 10)
+	if !ok {
+		return 0, fmt.Errorf("miningcore: invalid amount in base units: %q", units)
+	}
+	if decimals < 0 {
+		decimals = 0
+	}
+	f, _ := new(big.Rat).SetFrac(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)).Float64()
+	return Amount(f), nil
+}
+
+// NormalizePayment returns a copy of the payment with its amount converted from the smallest unit of coin
+// to whole coins, for pools that report payments in base units. The payment is returned unchanged
+// if the Decimals of coin are unknown.
+func (c *Client) NormalizePayment(coin Coin, p Payment) Payment {
+	p.Amount = float64(Amount(p.Amount).ToWholeCoins(coin.Decimals))
+	return p
+}
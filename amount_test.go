@@ -0,0 +1,50 @@
+package miningcore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToWholeCoins(t *testing.T) {
+	assert.Equal(t, Amount(1.5), Amount(150_000_000).ToWholeCoins(8))
+	assert.InDelta(t, 0.00000001, float64(Amount(1).ToWholeCoins(8)), 1e-20)
+	assert.InDelta(t, 2.25, float64(Amount(2.25e18).ToWholeCoins(18)), 1e-12)
+	assert.Equal(t, Amount(42), Amount(42).ToWholeCoins(0))
+	assert.Equal(t, Amount(42), Amount(42).ToWholeCoins(-1))
+}
+
+func TestFromBaseUnits(t *testing.T) {
+	a, err := FromBaseUnits("150000000", 8)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(1.5), a)
+
+	// exceeds int64
+	a, err = FromBaseUnits("12345000000000000000", 18)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(12.345), a)
+
+	a, err = FromBaseUnits("1", 18)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(1e-18), a)
+
+	_, err = FromBaseUnits("1.5", 8)
+	assert.Error(t, err)
+	_, err = FromBaseUnits("", 8)
+	assert.Error(t, err)
+}
+
+func TestNormalizePayment(t *testing.T) {
+	client := New("http://localhost")
+	p := Payment{Coin: "BTC", Amount: 250_000_000}
+	normalized := client.NormalizePayment(Coin{Symbol: "BTC", Decimals: 8}, p)
+	assert.Equal(t, 2.5, normalized.Amount)
+	assert.Equal(t, "BTC", normalized.Coin)
+	assert.Equal(t, float64(250_000_000), p.Amount)
+
+	eth := client.NormalizePayment(Coin{Symbol: "ETH", Decimals: 18}, Payment{Amount: 5e17})
+	assert.InDelta(t, 0.5, eth.Amount, 1e-12)
+
+	// unknown decimals keep the amount
+	assert.Equal(t, p, client.NormalizePayment(Coin{Symbol: "BTC"}, p))
+}
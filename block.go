@@ -0,0 +1,43 @@
+package miningcore
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// BlockStatus is the status of a block found by a pool.
+type BlockStatus string
+
+// Block statuses reported by miningcore. Statuses unknown to this package decode to BlockStatusUnknown.
+const (
+	BlockStatusPending   BlockStatus = "pending"
+	BlockStatusConfirmed BlockStatus = "confirmed"
+	BlockStatusOrphaned  BlockStatus = "orphaned"
+	BlockStatusUnknown   BlockStatus = "unknown"
+)
+
+// UnmarshalJSON decodes a block status case-insensitively.
+// Unexpected values and null decode to BlockStatusUnknown instead of failing.
+func (s *BlockStatus) UnmarshalJSON(data []byte) error {
+	var raw *string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*s = BlockStatusUnknown
+		return nil
+	}
+	*s = normalizeBlockStatus(BlockStatus(*raw))
+	return nil
+}
+
+// normalizeBlockStatus returns the lower case block status, mapping anything unexpected to BlockStatusUnknown.
+// Decoded statuses are already normalized, this handles blocks constructed by callers.
+func normalizeBlockStatus(status BlockStatus) BlockStatus {
+	switch s := BlockStatus(strings.ToLower(strings.TrimSpace(string(status)))); s {
+	case BlockStatusPending, BlockStatusConfirmed, BlockStatusOrphaned:
+		return s
+	default:
+		return BlockStatusUnknown
+	}
+}
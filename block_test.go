@@ -0,0 +1,36 @@
+package miningcore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockStatusUnmarshal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want BlockStatus
+	}{
+		{`"pending"`, BlockStatusPending},
+		{`"confirmed"`, BlockStatusConfirmed},
+		{`"orphaned"`, BlockStatusOrphaned},
+		{`"Confirmed"`, BlockStatusConfirmed},
+		{`"ORPHANED"`, BlockStatusOrphaned},
+		{`"reorged"`, BlockStatusUnknown},
+		{`""`, BlockStatusUnknown},
+		{`null`, BlockStatusUnknown},
+	}
+	for _, tt := range tests {
+		var b Block
+		assert.NoError(t, json.Unmarshal([]byte(`{"status": `+tt.in+`}`), &b), tt.in)
+		assert.Equal(t, tt.want, b.Status, tt.in)
+	}
+
+	var b Block
+	assert.Error(t, json.Unmarshal([]byte(`{"status": 1}`), &b))
+
+	data, err := json.Marshal(&Block{Status: BlockStatusConfirmed})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"status":"confirmed"`)
+}
@@ -0,0 +1,141 @@
+package miningcore
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a cached API response.
+type CacheEntry struct {
+	Body []byte `json:"body"`
+	// Header holds the headers of the response, except for cookies.
+	Header  http.Header `json:"header,omitempty"`
+	ETag    string      `json:"etag,omitempty"`
+	Expires time.Time   `json:"expires"`
+}
+
+// CacheStore stores cached responses, keyed by the request url.
+// Implementations can be backed by anything from a map to Redis or files,
+// and must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the entry for key or nil if there is none.
+	Get(key string) (*CacheEntry, error)
+	// Set stores the entry for key. The store may drop the entry after ttl.
+	Set(key string, entry *CacheEntry, ttl time.Duration) error
+	// Delete removes the entry for key.
+	Delete(key string) error
+}
+
+// WithPersistentCache caches pool and coin metadata, the responses of GetPools and GetPool, in store for ttl.
+// Entries that came with an ETag are kept for another ttl after they expired,
+// so they can be revalidated using If-None-Match instead of being downloaded again.
+// Cached responses are served with the headers they were received with.
+// Errors of the store are ignored and the request is sent as if there was no cache.
+//
+// Requests that carry credentials, see WithAuthToken and WithBasicAuth, are never cached, since the
+// store is shared and keyed by url only. Helpers that poll for changes, like WatchPoolStats, bypass the cache.
+func WithPersistentCache(store CacheStore, ttl time.Duration) ClientOpts {
+	return func(c *Client) {
+		c.cache = &responseCache{store: store, ttl: ttl}
+	}
+}
+
+type responseCache struct {
+	store CacheStore
+	ttl   time.Duration
+}
+
+func (r *responseCache) get(key string) *CacheEntry {
+	entry, err := r.store.Get(key)
+	if err != nil {
+		return nil
+	}
+	return entry
+}
+
+func (r *responseCache) set(key string, body []byte, header http.Header) {
+	header = header.Clone()
+	header.Del("Set-Cookie")
+	etag := header.Get("ETag")
+	retention := r.ttl
+	if etag != "" {
+		retention *= 2
+	}
+	_ = r.store.Set(key, &CacheEntry{
+		Body:    body,
+		Header:  header,
+		ETag:    etag,
+		Expires: time.Now().Add(r.ttl),
+	}, retention)
+}
+
+type cacheKey struct{}
+
+// withCache returns a context whose GET requests may be answered from the cache,
+// unless caching was disabled for it with withoutCache.
+func withCache(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(cacheKey{}).(bool); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, cacheKey{}, true)
+}
+
+// withoutCache returns a context whose requests always go to the server, for helpers that poll for changes.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheKey{}, false)
+}
+
+// cacheable reports whether req may be answered from and stored in the cache.
+func cacheable(req *http.Request) bool {
+	enabled, _ := req.Context().Value(cacheKey{}).(bool)
+	return enabled && req.Method == http.MethodGet && req.Header.Get("Authorization") == ""
+}
+
+// MemoryCacheStore is a CacheStore that keeps the entries in memory.
+type MemoryCacheStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	entry    *CacheEntry
+	deadline time.Time
+}
+
+// NewMemoryCacheStore returns an empty MemoryCacheStore.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{entries: make(map[string]memoryCacheEntry)}
+}
+
+// Get returns the entry for key or nil if there is none or it was kept longer than its ttl.
+func (m *MemoryCacheStore) Get(key string) (*CacheEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(e.deadline) {
+		delete(m.entries, key)
+		return nil, nil
+	}
+	return e.entry, nil
+}
+
+// Set stores the entry for key for ttl.
+func (m *MemoryCacheStore) Set(key string, entry *CacheEntry, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryCacheEntry{entry: entry, deadline: time.Now().Add(ttl)}
+	return nil
+}
+
+// Delete removes the entry for key.
+func (m *MemoryCacheStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
@@ -0,0 +1,169 @@
+package miningcore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore()
+	e, err := store.Get("a")
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+
+	assert.NoError(t, store.Set("a", &CacheEntry{Body: []byte("1")}, time.Hour))
+	e, err = store.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), e.Body)
+
+	assert.NoError(t, store.Delete("a"))
+	e, _ = store.Get("a")
+	assert.Nil(t, e)
+
+	assert.NoError(t, store.Set("b", &CacheEntry{Body: []byte("2")}, -time.Second))
+	e, _ = store.Get("b")
+	assert.Nil(t, e)
+}
+
+func TestPersistentCache(t *testing.T) {
+	var hits, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"pool": {"id": "eth"}}`))
+	}))
+	defer srv.Close()
+
+	store := NewMemoryCacheStore()
+	client := New(srv.URL, WithPersistentCache(store, 50*time.Millisecond))
+	for i := 0; i < 3; i++ {
+		pool, code, err := client.GetPool(context.Background(), "eth")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "eth", pool.ID)
+	}
+	assert.Equal(t, 1, hits)
+
+	// a second client sharing the store, like a new process, is served from the cache
+	pool, _, err := New(srv.URL, WithPersistentCache(store, 50*time.Millisecond)).GetPool(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, "eth", pool.ID)
+	assert.Equal(t, 1, hits)
+
+	// expired entries are revalidated with their ETag
+	time.Sleep(60 * time.Millisecond)
+	pool, code, err := client.GetPool(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "eth", pool.ID)
+	assert.Equal(t, 2, hits)
+	assert.Equal(t, 1, notModified)
+
+	_, _, err = client.GetPool(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, hits)
+}
+
+func TestPersistentCacheSkipsErrors(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client := New(srv.URL, WithPersistentCache(NewMemoryCacheStore(), time.Minute))
+	for i := 0; i < 2; i++ {
+		_, _, err := client.GetPool(context.Background(), "eth")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, hits)
+}
+
+func TestPersistentCacheHeaders(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("X-RateLimit-Limit", "10")
+		w.Header().Set("X-RateLimit-Remaining", "9")
+		w.Header().Set("Set-Cookie", "session=1")
+		w.Write([]byte(`{"pools": []}`))
+	}))
+	defer srv.Close()
+
+	store := NewMemoryCacheStore()
+	_, _, err := New(srv.URL, WithPersistentCache(store, time.Minute)).GetPools(context.Background())
+	assert.NoError(t, err)
+
+	// cached responses are served with their headers, except for cookies
+	client := New(srv.URL, WithPersistentCache(store, time.Minute))
+	resp, err := client.do(withCache(context.Background()), "/api/pools", http.MethodGet, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, "9", resp.Header.Get("X-RateLimit-Remaining"))
+	assert.Empty(t, resp.Header.Get("Set-Cookie"))
+	assert.Equal(t, 10, client.RateLimit().Limit)
+
+	// the Date header of a live response is needed, so the skew is never taken from the cache
+	for i := 0; i < 2; i++ {
+		_, err := client.ServerTimeSkew(context.Background())
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, hits)
+}
+
+func TestPersistentCacheScope(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		switch r.URL.Path {
+		case "/api/pools/eth":
+			fmt.Fprintf(w, `{"pool": {"id": "eth", "poolStats": {"connectedMiners": %d}}}`, hits)
+		default:
+			w.Write([]byte(`{"result": [], "meta": {"success": true}}`))
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	// only pool metadata is cached
+	client := New(srv.URL, WithPersistentCache(NewMemoryCacheStore(), time.Minute))
+	for i := 0; i < 2; i++ {
+		_, _, err := client.GetPoolBlocks(ctx, "eth")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, hits)
+
+	// requests with credentials are neither served from nor stored in the shared cache
+	hits = 0
+	store := NewMemoryCacheStore()
+	authed := New(srv.URL, WithPersistentCache(store, time.Minute), WithAuthToken("secret"))
+	for i := 0; i < 2; i++ {
+		_, _, err := authed.GetPool(ctx, "eth")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, hits)
+	pool, _, err := New(srv.URL, WithPersistentCache(store, time.Minute)).GetPool(ctx, "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), pool.PoolStats.ConnectedMiners)
+
+	// pollers always see the current state
+	hits = 0
+	client = New(srv.URL, WithPersistentCache(NewMemoryCacheStore(), time.Minute))
+	_, _, err = client.GetPool(ctx, "eth")
+	assert.NoError(t, err)
+	ev := client.pollPoolStats(ctx, "eth")
+	assert.NoError(t, ev.Err)
+	assert.Equal(t, int32(2), ev.ConnectedMiners)
+}
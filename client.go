@@ -5,11 +5,16 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -20,12 +25,115 @@ type ClientOpts func(*Client)
 func WithoutTLSVerfiy() ClientOpts {
 	// #nosec G402
 	return func(c *Client) {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
+		c.transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
 		}
-		c.http.Transport = tr
+	}
+}
+
+// WithUnixSocket dials the given unix domain socket for every request instead of
+// the host in the client url. The url is still used to build the request, so any
+// dummy host like "http://miningcore" works.
+func WithUnixSocket(path string) ClientOpts {
+	return func(c *Client) {
+		c.transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+	}
+}
+
+// WithDialContext establishes the connections of the client with fn, e.g. to resolve hosts with a custom
+// resolver, connect to a listener of a test or only allow certain IP addresses. addr is the host and port
+// of the client url. It replaces the dialer of WithUnixSocket, the option given last wins.
+func WithDialContext(fn func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOpts {
+	return func(c *Client) {
+		c.transport.DialContext = fn
+	}
+}
+
+// WithDisableKeepAlives closes the connection after every request.
+// This is useful for short-lived command line tools that should not hold idle connections.
+func WithDisableKeepAlives() ClientOpts {
+	return func(c *Client) {
+		c.transport.DisableKeepAlives = true
+	}
+}
+
+// WithRequestContentType sets the Content-Type header sent with request bodies.
+// It defaults to application/json.
+func WithRequestContentType(ct string) ClientOpts {
+	return func(c *Client) {
+		c.contentType = ct
+	}
+}
+
+// WithAuthToken sends the token as bearer token in the Authorization header of every request.
+// It takes precedence over WithBasicAuth.
+func WithAuthToken(token string) ClientOpts {
+	return func(c *Client) {
+		c.authToken = token
+	}
+}
+
+// WithBasicAuth sends the username and password with HTTP basic auth in the Authorization header of every request,
+// e.g. for reverse proxies that guard the API. WithAuthToken takes precedence if both are set.
+func WithBasicAuth(username, password string) ClientOpts {
+	return func(c *Client) {
+		c.basicAuth = &basicAuth{username: username, password: password}
+	}
+}
+
+type basicAuth struct {
+	username, password string
+}
+
+// SettingsSignatureHeader is the header that carries the signature created by the settings signer.
+const SettingsSignatureHeader = "X-Signature"
+
+// WithSettingsSigner sets a func that signs miner settings updates.
+// It is called with the pool ID, the miner address (after normalization) and the exact JSON encoded
+// request body, and its result is sent in the SettingsSignatureHeader header of the request.
+// The body is signed as sent, so the signature stays valid if the request is retried.
+//
+// miningcore itself only accepts settings updates if the ipAddress of the request matches
+// an IP address the miner recently submitted shares from and does not verify signatures.
+// A signer is needed for pools or proxies in front of them that additionally require a message
+// signed with the wallet of the miner.
+func WithSettingsSigner(fn func(poolId, address string, body []byte) (signature string, err error)) ClientOpts {
+	return func(c *Client) {
+		c.settingsSigner = fn
+	}
+}
+
+// WithTimeZone sets the time zone that times parsed by the client are converted to, which determines
+// the calendar days that daily aggregations like GetMinerEarningsSeries group by. The default is UTC.
+func WithTimeZone(loc *time.Location) ClientOpts {
+	return func(c *Client) {
+		if loc != nil {
+			c.location = loc
+		}
+	}
+}
+
+// WithSlowRequestThreshold calls fn with the endpoint and the duration of every request that takes longer than d,
+// whether it succeeds or fails. The duration covers the whole request including retries and reading the body.
+// fn is called synchronously before the request method returns, so it should not block.
+func WithSlowRequestThreshold(d time.Duration, fn func(endpoint string, dur time.Duration)) ClientOpts {
+	return func(c *Client) {
+		c.slowThreshold = d
+		c.onSlowRequest = fn
+	}
+}
+
+// WithCompositeTimeout limits the total duration of the helpers that combine several requests:
+// GetMinerDashboard, MinerPoolShare, TopMiners, TotalReportedHashrate, GetMinerAcrossPools, TotalBlockRewards,
+// PoolLuck, GetMinerEarningsSeries, EstimateMinerDailyEarnings, ExportMinerPaymentsCSV and DetectCapabilities.
+// All requests of a call share the budget and are canceled together once it is used up, so a single slow
+// request can't stall the whole call. A deadline of the context passed to the helper still applies if it is earlier.
+func WithCompositeTimeout(d time.Duration) ClientOpts {
+	return func(c *Client) {
+		c.compositeTimeout = d
 	}
 }
 
@@ -50,13 +158,166 @@ func WithJSONDecoder(decoder func(data []byte, v interface{}) error) ClientOpts
 	}
 }
 
+// WithMaxConcurrentRequests limits the number of requests that are in flight at the same time.
+// Additional requests wait until a slot is free or their context is canceled.
+func WithMaxConcurrentRequests(n int) ClientOpts {
+	return func(c *Client) {
+		if n > 0 {
+			c.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithResultTransform sets a hook that is called with the endpoint and the decoded
+// result after every successful request. It can be used to normalize the data of all
+// responses in one place. An error returned by the hook is returned by the request.
+func WithResultTransform(fn func(endpoint string, v any) error) ClientOpts {
+	return func(c *Client) {
+		c.resultTransform = fn
+	}
+}
+
+// WithMaxPageSize clamps the `pageSize` parameter of paginated requests to n, including page sizes set with
+// WithDefaultParams or WithExtraParams. miningcore caps the page size on the server, this makes the limit
+// explicit on the client. Clamped page sizes are reported to the logger, see WithLogger.
+func WithMaxPageSize(n int) ClientOpts {
+	return func(c *Client) {
+		c.maxPageSize = n
+	}
+}
+
+// Logger receives warnings of the client, *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// WithLogger sets the logger that receives warnings of the client, such as clamped page sizes.
+// By default warnings are discarded.
+func WithLogger(l Logger) ClientOpts {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// logf writes a warning to the logger, if any.
+func (c *Client) logf(format string, v ...any) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
+}
+
+// WithDefaultParams sets query parameters that are sent with every request.
+// Parameters from the context (see WithExtraParams) and of the call itself take precedence.
+func WithDefaultParams(params map[string]string) ClientOpts {
+	return func(c *Client) {
+		c.defaultParams = params
+	}
+}
+
+type extraParamsKey struct{}
+
+type requestHookKey struct{}
+
+// requestHook is called with every request created with a context carrying it and the encoded request body.
+type requestHook func(req *http.Request, body []byte) error
+
+// withRequestHook returns a context whose requests are passed to hook before they are sent.
+func withRequestHook(ctx context.Context, hook requestHook) context.Context {
+	return context.WithValue(ctx, requestHookKey{}, hook)
+}
+
+// WithExtraParams returns a context that adds the given query parameters to every request made with it,
+// for example a tenant selector required by a proxy. Parameters already set on the context are kept
+// unless they are overwritten.
+//
+// Parameters passed to a method override the ones from the context, which override the client defaults.
+func WithExtraParams(ctx context.Context, params map[string]string) context.Context {
+	merged := make(map[string]string)
+	if prev, ok := ctx.Value(extraParamsKey{}).(map[string]string); ok {
+		for k, v := range prev {
+			merged[k] = v
+		}
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, extraParamsKey{}, merged)
+}
+
+// Fields returns the `fields` parameter selecting the JSON fields of the items of a response, for servers or
+// proxies that support projections to reduce the size of large responses, such as those of GetMiners,
+// GetPoolBlocks and GetPoolPayments. It can be passed alongside other params:
+//
+//	c.GetPoolBlocks(ctx, "eth", Fields("blockHeight", "status"), map[string]string{"page": "0"})
+//
+// Servers that don't support projections ignore the parameter. Fields that are not selected are left zero.
+// Without fields, nil is returned and all fields are fetched.
+func Fields(fields ...string) map[string]string {
+	if len(fields) == 0 {
+		return nil
+	}
+	return map[string]string{"fields": strings.Join(fields, ",")}
+}
+
+// WithDefaultPageSize sets the `pageSize` parameter of paginated requests that don't set it or set it to 0.
+// A page size set by the call, the client params or the context, see WithExtraParams, takes precedence over
+// the client default, which takes precedence over the default of the server. The default is clamped by WithMaxPageSize as well.
+func WithDefaultPageSize(n int) ClientOpts {
+	return func(c *Client) {
+		c.defaultPageSize = n
+	}
+}
+
+// WithAddressNormalizer sets a func that is applied to miner addresses before they are used in a request path,
+// e.g. strings.ToLower for pools that store Ethereum addresses in lower case. By default addresses are used as passed.
+func WithAddressNormalizer(fn func(string) string) ClientOpts {
+	return func(c *Client) {
+		c.addressNormalizer = fn
+	}
+}
+
 // Client represents a client for the miningcore API.
 type Client struct {
 	timeout     time.Duration
 	url         string
 	http        *http.Client
+	transport   *http.Transport
 	jsonEncoder func(v interface{}) ([]byte, error)
 	jsonDecoder func(data []byte, v interface{}) error
+	sem         chan struct{}
+	maxPageSize int
+	logger      Logger
+
+	defaultPageSize int
+
+	addressNormalizer func(string) string
+
+	defaultParams map[string]string
+	cache         *responseCache
+
+	contentType    string
+	authToken      string
+	basicAuth      *basicAuth
+	redirectPolicy RedirectPolicy
+
+	settingsSigner func(poolId, address string, body []byte) (string, error)
+
+	location *time.Location
+
+	compositeTimeout time.Duration
+
+	slowThreshold time.Duration
+	onSlowRequest func(endpoint string, dur time.Duration)
+
+	retry retryConfig
+
+	rateLimitMu sync.Mutex
+	rateLimit   RateLimitInfo
+
+	resultTransform func(endpoint string, v any) error
+
+	recorder io.Writer
+	replay   http.RoundTripper
 }
 
 // New creates a new client for the miningcore API.
@@ -66,77 +327,328 @@ func New(url string, opts ...ClientOpts) *Client {
 		url:         strings.TrimSuffix(url, "/"),
 		jsonEncoder: json.Marshal,
 		jsonDecoder: json.Unmarshal,
+		contentType: "application/json",
+		location:    time.UTC,
 		http:        &http.Client{},
+		transport:   http.DefaultTransport.(*http.Transport).Clone(),
 	}
 	for _, opt := range opts {
 		opt(c)
 	}
-	c.http = &http.Client{Timeout: c.timeout}
+	var transport http.RoundTripper = c.transport
+	if c.replay != nil {
+		transport = c.replay
+	}
+	if c.recorder != nil {
+		transport = newRecordingTransport(transport, c.recorder, c.url)
+	}
+	c.http = &http.Client{
+		Timeout:       c.timeout,
+		Transport:     transport,
+		CheckRedirect: c.redirectPolicy.checkRedirect(),
+	}
 	return c
 }
 
 // doRequest performs the actual request to the miningcore API.
 func (c *Client) doRequest(ctx context.Context, endpoint, method string, expRes, reqData any, params ...map[string]string) (int, error) {
-	callURL, err := buildRequestURL(c.url, endpoint, params...)
+	resp, err := c.do(ctx, endpoint, method, expRes, reqData, params...)
+	return statusCode(resp), err
+}
+
+// address returns addr after applying the address normalizer.
+func (c *Client) address(addr string) string {
+	if c.addressNormalizer == nil {
+		return addr
+	}
+	return c.addressNormalizer(addr)
+}
+
+// ParseTime parses a timestamp of the API, such as DailyEarning.Date or PoolPerformance.Created,
+// and returns it in the time zone of the client, see WithTimeZone.
+func (c *Client) ParseTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
 	if err != nil {
-		return 0, err
+		return time.Time{}, err
 	}
+	return t.In(c.location), nil
+}
 
-	var dataReq []byte
-	if reqData != nil {
-		dataReq, err = c.jsonEncoder(reqData)
-		if err != nil {
-			return 0, err
+// statusCode returns the status code of resp or 0 if there is no response.
+func statusCode(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+// do performs the request and returns the response, whose body has already been consumed.
+// The response is nil if the request failed before a status code was received or the body could not be decoded.
+func (c *Client) do(ctx context.Context, endpoint, method string, expRes, reqData any, params ...map[string]string) (*http.Response, error) {
+	defer c.reportSlow(endpoint, time.Now())
+	req, err := c.newRequest(ctx, endpoint, method, reqData, params...)
+	if err != nil {
+		return nil, err
+	}
+	callURL := req.URL.String()
+
+	useCache := c.cache != nil && cacheable(req)
+	var cached *CacheEntry
+	if useCache {
+		cached = c.cache.get(callURL)
+		if cached != nil && time.Now().Before(cached.Expires) {
+			if err := c.decode(endpoint, cached.Body, expRes); err != nil {
+				return nil, err
+			}
+			header := cached.Header.Clone()
+			if header == nil {
+				header = make(http.Header)
+			}
+			c.updateRateLimit(header)
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Request: req}, nil
+		}
+		if cached != nil && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, callURL, bytes.NewBuffer(dataReq))
+	resp, body, err := c.send(ctx, req, false)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	if dataReq != nil {
-		req.Header.Add("Content-Type", "application/json")
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.StatusCode = http.StatusOK
+		body = cached.Body
+		// a 304 only carries some of the headers, the others are those of the cached response
+		for k, v := range cached.Header {
+			if _, ok := resp.Header[k]; !ok {
+				resp.Header[k] = v
+			}
+		}
 	}
 
+	switch resp.StatusCode {
+	case 200:
+		if err := c.decode(endpoint, body, expRes); err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				// the server reported a failure despite the status
+				return resp, err
+			}
+			return nil, err
+		}
+		if useCache {
+			c.cache.set(callURL, body, resp.Header)
+		}
+		return resp, nil
+
+	default:
+		return resp, responseError(endpoint, resp, body)
+	}
+}
+
+// reportSlow calls the slow request callback if the request to endpoint that began at start took longer than the threshold.
+func (c *Client) reportSlow(endpoint string, start time.Time) {
+	if c.onSlowRequest == nil {
+		return
+	}
+	if dur := time.Since(start); dur > c.slowThreshold {
+		c.onSlowRequest(endpoint, dur)
+	}
+}
+
+// roundTrip sends req once and reads the response body, then calls release.
+// If stream is set, the body of a 200 response is not read but returned open as *streamBody,
+// which calls release once it is closed.
+func (c *Client) roundTrip(req *http.Request, stream bool, release func()) (*http.Response, []byte, error) {
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return 0, err
+		release()
+		return nil, nil, err
 	}
+	c.updateRateLimit(resp.Header)
+	if stream && resp.StatusCode == http.StatusOK {
+		resp.Body = &streamBody{ReadCloser: resp.Body, release: release}
+		return resp, nil, nil
+	}
+	defer release()
 	defer resp.Body.Close()
+	if req.Method == http.MethodHead {
+		return resp, nil, nil
+	}
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// newRequest builds the request for the endpoint, encoding reqData as body if set.
+func (c *Client) newRequest(ctx context.Context, endpoint, method string, reqData any, params ...map[string]string) (*http.Request, error) {
+	callURL, err := buildRequestURL(c.url, endpoint, c.requestParams(ctx, params...))
+	if err != nil {
+		return nil, err
 	}
 
-	switch resp.StatusCode {
-	case 200:
-		if expRes != nil {
-			err = c.jsonDecoder(body, expRes)
-			if err != nil {
-				return 0, err
-			}
+	var dataReq []byte
+	if reqData != nil {
+		dataReq, err = c.jsonEncoder(reqData)
+		if err != nil {
+			return nil, err
 		}
-		return resp.StatusCode, nil
+	}
 
-	default:
-		return resp.StatusCode, fmt.Errorf("%s", body)
+	req, err := http.NewRequestWithContext(ctx, method, callURL, bytes.NewBuffer(dataReq))
+	if err != nil {
+		return nil, err
+	}
+	if dataReq != nil {
+		req.Header.Add("Content-Type", c.contentType)
+	}
+	switch {
+	case c.authToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.authToken)
+	case c.basicAuth != nil:
+		req.SetBasicAuth(c.basicAuth.username, c.basicAuth.password)
+	}
+	if hook, ok := ctx.Value(requestHookKey{}).(requestHook); ok {
+		if err := hook(req, dataReq); err != nil {
+			return nil, err
+		}
 	}
+	return req, nil
 }
 
+// acquire waits for a free request slot if the number of concurrent requests is limited.
+// The returned func releases the slot.
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	if c.sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.sem <- struct{}{}:
+		return func() { <-c.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// decode decodes body into expRes, if set, and applies the result transform.
+// Enveloped responses are unwrapped first, see unwrapEnvelope.
+func (c *Client) decode(endpoint string, body []byte, expRes any) error {
+	body, err := unwrapEnvelope(endpoint, body)
+	if err != nil {
+		return err
+	}
+	if expRes == nil {
+		return nil
+	}
+	if err := c.jsonDecoder(body, expRes); err != nil {
+		return err
+	}
+	if c.resultTransform != nil {
+		return c.resultTransform(endpoint, expRes)
+	}
+	return nil
+}
+
+// buildRequestURL joins the path of base with endpoint and adds the params to the query of base.
+// Later param maps override earlier ones, which override the query of base.
 func buildRequestURL(base, endpoint string, params ...map[string]string) (string, error) {
 	u, err := url.Parse(base)
 	if err != nil {
 		return "", err
 	}
-	u.Path = endpoint
-	if len(params) == 0 {
-		return u.String(), nil
+	// endpoints are escaped paths, segments may contain escaped slashes
+	rawPath := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + strings.TrimPrefix(endpoint, "/")
+	u.Path, err = url.PathUnescape(rawPath)
+	if err != nil {
+		return "", err
 	}
-	p := url.Values{}
-	for k, v := range params[0] {
-		p.Set(k, v)
+	u.RawPath = rawPath
+	q := u.Query()
+	for _, m := range params {
+		for k, v := range m {
+			q.Set(k, v)
+		}
 	}
-	u.RawQuery = p.Encode()
+	u.RawQuery = q.Encode()
 	return u.String(), nil
 }
+
+// endpoint formats an endpoint path with the given dynamic segments, such as pool IDs and miner addresses.
+// Segments are path escaped, so slashes in them don't change the route, and empty, "." and ".."
+// segments are rejected. Duplicate slashes in the result are collapsed.
+func endpoint(format string, segments ...string) (string, error) {
+	args := make([]any, len(segments))
+	for i, s := range segments {
+		if s == "" || s == "." || s == ".." {
+			return "", fmt.Errorf("%w: %q", ErrInvalidPathSegment, s)
+		}
+		args[i] = url.PathEscape(s)
+	}
+	e := fmt.Sprintf(format, args...)
+	for strings.Contains(e, "//") {
+		e = strings.ReplaceAll(e, "//", "/")
+	}
+	return e, nil
+}
+
+// requestParams merges the client defaults, the context params and the call params, in order of precedence.
+func (c *Client) requestParams(ctx context.Context, params ...map[string]string) map[string]string {
+	ctxParams, _ := ctx.Value(extraParamsKey{}).(map[string]string)
+	p := make(map[string]string)
+	for _, m := range append([]map[string]string{c.defaultParams, ctxParams}, params...) {
+		for k, v := range m {
+			p[k] = v
+		}
+	}
+	return p
+}
+
+// PaginationError is returned when a pagination parameter is not a valid, non-negative number.
+type PaginationError struct {
+	Param string
+	Value string
+}
+
+func (e *PaginationError) Error() string {
+	return fmt.Sprintf("miningcore: invalid pagination parameter %s=%q", e.Param, e.Value)
+}
+
+// pageParams merges the params of a paginated request like requestParams and validates the `page` and `pageSize`
+// parameters of the result, wherever they were set. If no layer set a page size, or the merged one is zero,
+// the default page size is used. The page size is clamped to the configured maximum.
+func (c *Client) pageParams(ctx context.Context, params ...map[string]string) (map[string]string, error) {
+	p := c.requestParams(ctx, params...)
+	if v, ok := p["pageSize"]; c.defaultPageSize > 0 && (!ok || v == "0") {
+		p["pageSize"] = strconv.Itoa(c.defaultPageSize)
+	}
+	for _, k := range []string{"page", "pageSize"} {
+		v, ok := p[k]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, &PaginationError{Param: k, Value: v}
+		}
+		if k == "pageSize" {
+			if clamped := c.clampPageSize(n); clamped != n {
+				c.logf("miningcore: pageSize %d exceeds the maximum of %d and was clamped", n, clamped)
+				p[k] = strconv.Itoa(clamped)
+			}
+		}
+	}
+	return p, nil
+}
+
+// clampPageSize returns n limited to the maximum page size, see WithMaxPageSize.
+// Helpers that page through results use it to know the size of a full page.
+func (c *Client) clampPageSize(n int) int {
+	if c.maxPageSize > 0 && n > c.maxPageSize {
+		return c.maxPageSize
+	}
+	return n
+}
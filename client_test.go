@@ -0,0 +1,557 @@
+package miningcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "mc.sock")
+	l, err := net.Listen("unix", sock)
+	assert.NoError(t, err)
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools", poolsReq)
+	srv := &http.Server{Handler: handler}
+	go srv.Serve(l) // nolint:errcheck
+	t.Cleanup(func() {
+		srv.Close()
+	})
+
+	client := New("http://miningcore", WithUnixSocket(sock))
+	pools, code, err := client.GetPools(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, len(pools))
+}
+
+func TestDialContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(poolsReq))
+	defer srv.Close()
+
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		if addr != "pool.invalid:80" {
+			return nil, errors.New("address not allowed")
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+
+	client := New("http://pool.invalid", WithDialContext(dial))
+	pools, code, err := client.GetPools(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, len(pools))
+	assert.Equal(t, []string{"pool.invalid:80"}, dialed)
+
+	_, _, err = New("http://other.invalid:8080", WithDialContext(dial)).GetPools(context.Background())
+	assert.ErrorContains(t, err, "address not allowed")
+	assert.Equal(t, "other.invalid:8080", dialed[1])
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	client := New(srv.URL, WithMaxConcurrentRequests(1))
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.UnmarshalPools(context.Background(), nil)
+		first <- err
+	}()
+	<-entered
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := client.UnmarshalPools(context.Background(), nil)
+		second <- err
+	}()
+	select {
+	case <-entered:
+		t.Fatal("second request reached the server while the first was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-first)
+	assert.NoError(t, <-second)
+}
+
+func TestMaxConcurrentRequestsContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	client := New(srv.URL, WithMaxConcurrentRequests(1))
+
+	go client.UnmarshalPools(context.Background(), nil) // nolint:errcheck
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.UnmarshalPools(ctx, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestResultTransform(t *testing.T) {
+	var endpoints []string
+	client := New(testServer.URL, WithResultTransform(func(endpoint string, v any) error {
+		endpoints = append(endpoints, endpoint)
+		if res, ok := v.(*struct {
+			Pool PoolInfo `json:"pool"`
+		}); ok {
+			res.Pool.Address = strings.ToLower(res.Pool.Address)
+		}
+		return nil
+	}))
+	pool, _, err := client.GetPool(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x000000000000000000000000000000000000dead", pool.Address)
+	assert.Equal(t, []string{"/api/pools/eth"}, endpoints)
+
+	client = New(testServer.URL, WithResultTransform(func(string, any) error {
+		return errors.New("transform failed")
+	}))
+	_, _, err = client.GetPool(context.Background(), "eth")
+	assert.EqualError(t, err, "transform failed")
+}
+
+func TestDisableKeepAlives(t *testing.T) {
+	client := New(testServer.URL, WithDisableKeepAlives(), WithoutTLSVerfiy())
+	assert.True(t, client.transport.DisableKeepAlives)
+	assert.True(t, client.transport.TLSClientConfig.InsecureSkipVerify)
+
+	var closed bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		closed = r.Close
+	}))
+	defer srv.Close()
+	_, err := New(srv.URL, WithDisableKeepAlives()).UnmarshalPools(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.True(t, closed)
+}
+
+func TestPageParams(t *testing.T) {
+	client := New(testServer.URL, WithMaxPageSize(100))
+
+	p, err := client.pageParams(context.Background(), map[string]string{"page": "0", "pageSize": "0"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"page": "0", "pageSize": "0"}, p)
+
+	p, err = client.pageParams(context.Background(), map[string]string{"pageSize": "100"})
+	assert.NoError(t, err)
+	assert.Equal(t, "100", p["pageSize"])
+
+	p, err = client.pageParams(context.Background(), map[string]string{"pageSize": "101"})
+	assert.NoError(t, err)
+	assert.Equal(t, "100", p["pageSize"])
+
+	p, err = client.pageParams(context.Background(), map[string]string{"pageSize": "100000", "state": "Confirmed"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"pageSize": "100", "state": "Confirmed"}, p)
+
+	p, err = New(testServer.URL).pageParams(context.Background(), map[string]string{"pageSize": "100000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "100000", p["pageSize"])
+
+	var perr *PaginationError
+	_, err = client.pageParams(context.Background(), map[string]string{"page": "-1"})
+	assert.ErrorAs(t, err, &perr)
+	assert.Equal(t, "page", perr.Param)
+
+	_, err = client.pageParams(context.Background(), map[string]string{"pageSize": "-1"})
+	assert.ErrorAs(t, err, &perr)
+	assert.Equal(t, "pageSize", perr.Param)
+
+	_, err = client.pageParams(context.Background(), map[string]string{"pageSize": "ten"})
+	assert.ErrorAs(t, err, &perr)
+
+	// invalid parameters are rejected before the request is sent
+	_, code, err := client.GetPoolBlocks(context.Background(), "eth", map[string]string{"page": "-1"})
+	assert.ErrorAs(t, err, &perr)
+	assert.Equal(t, 0, code)
+}
+
+func TestPageParamsAllLayers(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"pageCount": 0, "success": true, "result": []}`))
+	}))
+	defer srv.Close()
+	var warnings []string
+	logger := loggerFunc(func(format string, v ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, v...))
+	})
+
+	// page sizes of the context are clamped as well
+	client := New(srv.URL, WithMaxPageSize(50), WithLogger(logger))
+	ctx := WithExtraParams(context.Background(), map[string]string{"pageSize": "100000"})
+	_, _, err := client.GetPoolBlocks(ctx, "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, "50", query.Get("pageSize"))
+	assert.Equal(t, []string{"miningcore: pageSize 100000 exceeds the maximum of 50 and was clamped"}, warnings)
+
+	_, _, err = New(srv.URL, WithMaxPageSize(50), WithDefaultParams(map[string]string{"pageSize": "80"})).GetPoolBlocks(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, "50", query.Get("pageSize"))
+
+	// and invalid ones are rejected
+	var perr *PaginationError
+	query = nil
+	_, code, err := New(srv.URL, WithDefaultParams(map[string]string{"pageSize": "-5"})).GetPoolBlocks(context.Background(), "eth")
+	assert.ErrorAs(t, err, &perr)
+	assert.Equal(t, "pageSize", perr.Param)
+	assert.Equal(t, 0, code)
+	assert.Nil(t, query)
+
+	_, _, err = client.GetPoolBlocks(WithExtraParams(context.Background(), map[string]string{"page": "x"}), "eth")
+	assert.ErrorAs(t, err, &perr)
+	assert.Equal(t, "page", perr.Param)
+}
+
+type loggerFunc func(format string, v ...any)
+
+func (f loggerFunc) Printf(format string, v ...any) {
+	f(format, v...)
+}
+
+func TestExtraParams(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"pageCount": 0, "success": true, "result": []}`))
+	}))
+	defer srv.Close()
+	client := New(srv.URL, WithDefaultParams(map[string]string{"tenant": "default", "region": "eu", "pageSize": "5"}))
+
+	ctx := WithExtraParams(context.Background(), map[string]string{"tenant": "ctx", "trace": "1"})
+	ctx = WithExtraParams(ctx, map[string]string{"pageSize": "10"})
+	_, _, err := client.GetPoolBlocks(ctx, "eth", map[string]string{"pageSize": "20"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ctx", query.Get("tenant"))
+	assert.Equal(t, "eu", query.Get("region"))
+	assert.Equal(t, "1", query.Get("trace"))
+	assert.Equal(t, "20", query.Get("pageSize"))
+
+	_, _, err = client.GetPoolBlocks(ctx, "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, "10", query.Get("pageSize"))
+
+	_, _, err = client.GetPoolBlocks(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", query.Get("tenant"))
+	assert.Equal(t, "5", query.Get("pageSize"))
+	assert.Empty(t, query.Get("trace"))
+}
+
+func TestRequestContentType(t *testing.T) {
+	var contentType []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Values("Content-Type")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	settings := &MinerSettingsUpdateReq{Settings: &MinerSettings{PaymentThreshold: 1}}
+
+	_, err := New(srv.URL).UnmarshalPostMinerSettings(ctx, "eth", testMiner, settings, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"application/json"}, contentType)
+
+	client := New(srv.URL, WithRequestContentType("application/json; charset=utf-8"))
+	_, err = client.UnmarshalPostMinerSettings(ctx, "eth", testMiner, settings, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"application/json; charset=utf-8"}, contentType)
+
+	// requests without a body don't send a content type
+	_, err = client.UnmarshalPools(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, contentType)
+}
+
+func TestBasicAuth(t *testing.T) {
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Values("Authorization")
+		w.Write([]byte(`{"pools": []}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	_, _, err := New(srv.URL, WithBasicAuth("miner", "s3cret:pw")).GetPools(ctx)
+	assert.NoError(t, err)
+	// base64 of "miner:s3cret:pw"
+	assert.Equal(t, []string{"Basic bWluZXI6czNjcmV0OnB3"}, auth)
+
+	// the auth token takes precedence in any order
+	_, _, err = New(srv.URL, WithBasicAuth("miner", "pw"), WithAuthToken("token")).GetPools(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bearer token"}, auth)
+	_, _, err = New(srv.URL, WithAuthToken("token"), WithBasicAuth("miner", "pw")).GetPools(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bearer token"}, auth)
+
+	_, _, err = New(srv.URL).GetPools(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, auth)
+}
+
+func TestDefaultPageSize(t *testing.T) {
+	client := New(testServer.URL, WithDefaultPageSize(25))
+
+	p, err := client.pageParams(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"pageSize": "25"}, p)
+
+	p, err = client.pageParams(context.Background(), map[string]string{"page": "1", "pageSize": "0"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"page": "1", "pageSize": "25"}, p)
+
+	p, err = client.pageParams(context.Background(), map[string]string{"pageSize": "10"})
+	assert.NoError(t, err)
+	assert.Equal(t, "10", p["pageSize"])
+
+	// page sizes of the context and the client params outrank the default
+	p, err = New(testServer.URL, WithDefaultPageSize(20)).pageParams(WithExtraParams(context.Background(), map[string]string{"pageSize": "10"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "10", p["pageSize"])
+	p, err = New(testServer.URL, WithDefaultPageSize(20), WithDefaultParams(map[string]string{"pageSize": "15"})).pageParams(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "15", p["pageSize"])
+
+	p, err = New(testServer.URL, WithDefaultPageSize(500), WithMaxPageSize(100)).pageParams(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "100", p["pageSize"])
+
+	// without a default the server default is used
+	p, err = New(testServer.URL).pageParams(context.Background(), map[string]string{"pageSize": "0"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0", p["pageSize"])
+	p, err = New(testServer.URL).pageParams(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, p)
+}
+
+func TestAddressNormalizer(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	client := New(srv.URL, WithAddressNormalizer(strings.ToLower))
+	_, _, err := client.GetMiner(ctx, "eth", testMiner)
+	assert.NoError(t, err)
+	_, _, err = client.GetMinerPayments(ctx, "eth", testMiner)
+	assert.NoError(t, err)
+	_, _, err = client.GetMinerSettings(ctx, "eth", testMiner)
+	assert.NoError(t, err)
+	lower := strings.ToLower(testMiner)
+	assert.Equal(t, []string{
+		"/api/pools/eth/miners/" + lower,
+		"/api/v2/pools/eth/miners/" + lower + "/payments",
+		"/api/pools/eth/miners/" + lower + "/settings",
+	}, paths)
+
+	// addresses are used as passed by default
+	paths = nil
+	_, _, err = New(srv.URL).GetMiner(ctx, "eth", testMiner)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/api/pools/eth/miners/" + testMiner}, paths)
+}
+
+func TestSettingsSigner(t *testing.T) {
+	var (
+		method, signature string
+		body              []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		signature = r.Header.Get(SettingsSignatureHeader)
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`{"paymentThreshold": 1}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	settings := &MinerSettingsUpdateReq{IPAddress: "127.0.0.1", Settings: &MinerSettings{PaymentThreshold: 1}}
+
+	var signed []byte
+	client := New(srv.URL, WithAddressNormalizer(strings.ToLower), WithSettingsSigner(func(poolId, address string, body []byte) (string, error) {
+		assert.Equal(t, "eth", poolId)
+		assert.Equal(t, strings.ToLower(testMiner), address)
+		signed = body
+		return "sig:" + poolId, nil
+	}))
+	res, _, err := client.PostMinerSettings(ctx, "eth", testMiner, settings)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, res.PaymentThreshold)
+	assert.Equal(t, http.MethodPost, method)
+	assert.Equal(t, "sig:eth", signature)
+	assert.Equal(t, body, signed)
+	assert.JSONEq(t, `{"ipAddress": "127.0.0.1", "settings": {"paymentThreshold": 1}}`, string(body))
+
+	// requests other than settings updates are not signed
+	_, _, err = client.GetMinerSettings(ctx, "eth", testMiner)
+	assert.NoError(t, err)
+	assert.Empty(t, signature)
+
+	client = New(srv.URL, WithSettingsSigner(func(string, string, []byte) (string, error) {
+		return "", errors.New("no key")
+	}))
+	method = ""
+	_, _, err = client.PostMinerSettings(ctx, "eth", testMiner, settings)
+	assert.ErrorContains(t, err, "no key")
+	assert.Empty(t, method)
+}
+
+func TestEndpoint(t *testing.T) {
+	e, err := endpoint("/api/pools/%s/miners/%s", "eth", testMiner)
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/pools/eth/miners/"+testMiner, e)
+
+	e, err = endpoint("/api/pools/%s/blocks", "eth/../admin")
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/pools/eth%2F..%2Fadmin/blocks", e)
+
+	e, err = endpoint("/api/pools/%s", "eth.classic")
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/pools/eth.classic", e)
+
+	e, err = endpoint("/api//pools/%s/", "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/pools/eth/", e)
+
+	for _, id := range []string{"", ".", ".."} {
+		_, err = endpoint("/api/pools/%s/blocks", id)
+		assert.ErrorIs(t, err, ErrInvalidPathSegment, id)
+	}
+}
+
+func TestEndpointRequests(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	_, _, err := client.GetPool(ctx, "a/b")
+	assert.NoError(t, err)
+	_, _, err = client.GetMiner(ctx, "eth", "../../admin")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/api/pools/a%2Fb", "/api/pools/eth/miners/..%2F..%2Fadmin"}, paths)
+
+	// invalid segments are rejected before a request is sent
+	paths = nil
+	_, code, err := client.GetPoolBlocks(ctx, "")
+	assert.ErrorIs(t, err, ErrInvalidPathSegment)
+	assert.Equal(t, 0, code)
+	_, _, err = client.GetMiner(ctx, "eth", "..")
+	assert.ErrorIs(t, err, ErrInvalidPathSegment)
+	err = client.StreamPoolBlocks(ctx, ".", func(*Block) error { return nil })
+	assert.ErrorIs(t, err, ErrInvalidPathSegment)
+	ok, err := client.PoolExists(ctx, "")
+	assert.ErrorIs(t, err, ErrInvalidPathSegment)
+	assert.False(t, ok)
+	assert.Empty(t, paths)
+}
+
+func TestSlowRequestThreshold(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") != "" {
+			time.Sleep(60 * time.Millisecond)
+		}
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"pools": []}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	type slow struct {
+		endpoint string
+		dur      time.Duration
+	}
+	var reported []slow
+	client := New(srv.URL, WithSlowRequestThreshold(30*time.Millisecond, func(endpoint string, dur time.Duration) {
+		reported = append(reported, slow{endpoint, dur})
+	}))
+
+	_, err := client.UnmarshalPools(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, reported)
+
+	_, err = client.UnmarshalPools(WithExtraParams(ctx, map[string]string{"slow": "1"}), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(reported))
+	assert.Equal(t, "/api/pools", reported[0].endpoint)
+	assert.GreaterOrEqual(t, reported[0].dur, 60*time.Millisecond)
+
+	_, err = client.UnmarshalPools(WithExtraParams(ctx, map[string]string{"slow": "1", "fail": "1"}), nil)
+	assert.Error(t, err)
+	assert.Equal(t, 2, len(reported))
+
+	_, err = client.UnmarshalPools(WithExtraParams(ctx, map[string]string{"fail": "1"}), nil)
+	assert.Error(t, err)
+	assert.Equal(t, 2, len(reported))
+}
+
+func TestFields(t *testing.T) {
+	assert.Nil(t, Fields())
+	assert.Equal(t, map[string]string{"fields": "miner,hashrate"}, Fields("miner", "hashrate"))
+
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if query.Get("fields") != "" {
+			w.Write([]byte(`[{"miner": "a"}, {"miner": "b"}]`))
+			return
+		}
+		w.Write([]byte(`[{"miner": "a", "hashrate": 10, "sharesPerSecond": 1}, {"miner": "b", "hashrate": 5, "sharesPerSecond": 0.5}]`))
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	miners, _, err := client.GetMiners(ctx, "eth", Fields("miner"), map[string]string{"page": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "miner", query.Get("fields"))
+	assert.Equal(t, "1", query.Get("page"))
+	assert.Equal(t, 2, len(miners))
+	assert.Equal(t, "b", miners[1].Miner)
+	assert.Equal(t, 0.0, miners[1].Hashrate)
+
+	miners, _, err = client.GetMiners(ctx, "eth", Fields())
+	assert.NoError(t, err)
+	_, ok := query["fields"]
+	assert.False(t, ok)
+	assert.Equal(t, 5.0, miners[1].Hashrate)
+}
@@ -0,0 +1,102 @@
+package miningcore
+
+import "strings"
+
+// Algorithm is the mining algorithm of a coin as reported by miningcore.
+// Algorithms without a constant are kept as reported.
+type Algorithm string
+
+// Common algorithms supported by miningcore.
+const (
+	AlgorithmSHA256      Algorithm = "SHA256"
+	AlgorithmScrypt      Algorithm = "Scrypt"
+	AlgorithmX11         Algorithm = "X11"
+	AlgorithmEquihash    Algorithm = "Equihash"
+	AlgorithmEthash      Algorithm = "Ethhash"
+	AlgorithmEtchash     Algorithm = "Etchash"
+	AlgorithmRandomX     Algorithm = "RandomX"
+	AlgorithmCryptonight Algorithm = "Cryptonight"
+	AlgorithmKawPow      Algorithm = "KawPow"
+	AlgorithmAutolykos   Algorithm = "Autolykos"
+	AlgorithmKHeavyHash  Algorithm = "kHeavyHash"
+	AlgorithmVerthash    Algorithm = "Verthash"
+)
+
+var knownAlgorithms = []Algorithm{
+	AlgorithmSHA256,
+	AlgorithmScrypt,
+	AlgorithmX11,
+	AlgorithmEquihash,
+	AlgorithmEthash,
+	AlgorithmEtchash,
+	AlgorithmRandomX,
+	AlgorithmCryptonight,
+	AlgorithmKawPow,
+	AlgorithmAutolykos,
+	AlgorithmKHeavyHash,
+	AlgorithmVerthash,
+}
+
+// Is reports whether a and other are the same algorithm, ignoring case.
+func (a Algorithm) Is(other Algorithm) bool {
+	return strings.EqualFold(string(a), string(other))
+}
+
+// Known reports whether a is one of the algorithm constants.
+func (a Algorithm) Known() bool {
+	for _, k := range knownAlgorithms {
+		if a.Is(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// CoinFamily is the family of a coin as reported by miningcore, which determines how the pool talks to its daemon.
+// Families without a constant are kept as reported.
+type CoinFamily string
+
+// Coin families supported by miningcore.
+const (
+	FamilyBitcoin    CoinFamily = "bitcoin"
+	FamilyEquihash   CoinFamily = "equihash"
+	FamilyCryptonote CoinFamily = "cryptonote"
+	FamilyConceal    CoinFamily = "conceal"
+	FamilyEthereum   CoinFamily = "ethereum"
+	FamilyErgo       CoinFamily = "ergo"
+	FamilyBeam       CoinFamily = "beam"
+	FamilyKaspa      CoinFamily = "kaspa"
+)
+
+var knownFamilies = []CoinFamily{
+	FamilyBitcoin,
+	FamilyEquihash,
+	FamilyCryptonote,
+	FamilyConceal,
+	FamilyEthereum,
+	FamilyErgo,
+	FamilyBeam,
+	FamilyKaspa,
+}
+
+// Is reports whether f and other are the same family, ignoring case.
+func (f CoinFamily) Is(other CoinFamily) bool {
+	return strings.EqualFold(string(f), string(other))
+}
+
+// Known reports whether f is one of the family constants.
+func (f CoinFamily) Known() bool {
+	for _, k := range knownFamilies {
+		if f.Is(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProofOfWork reports whether the coin is known to be mined with proof of work.
+// All families and algorithms supported by miningcore are proof of work, coins with
+// an unknown family and algorithm are not assumed to be.
+func (c APICoinConfig) IsProofOfWork() bool {
+	return c.Family.Known() || c.Algorithm.Known()
+}
@@ -0,0 +1,37 @@
+package miningcore
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoinAlgorithm(t *testing.T) {
+	pool, _, err := newClient().GetPool(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, AlgorithmEthash, pool.Coin.Algorithm)
+	assert.Equal(t, FamilyEthereum, pool.Coin.Family)
+	assert.True(t, pool.Coin.IsProofOfWork())
+
+	assert.True(t, Algorithm("sha256").Is(AlgorithmSHA256))
+	assert.True(t, Algorithm("khEAVYhash").Known())
+	assert.True(t, CoinFamily("Bitcoin").Is(FamilyBitcoin))
+	assert.False(t, CoinFamily("newcoin").Known())
+}
+
+func TestCoinUnknownAlgorithm(t *testing.T) {
+	data := []byte(`{"name": "NewCoin", "family": "newfamily", "algorithm": "FancyHash"}`)
+	var coin APICoinConfig
+	assert.NoError(t, json.Unmarshal(data, &coin))
+	assert.Equal(t, Algorithm("FancyHash"), coin.Algorithm)
+	assert.Equal(t, CoinFamily("newfamily"), coin.Family)
+	assert.False(t, coin.Algorithm.Known())
+	assert.False(t, coin.IsProofOfWork())
+
+	out, err := json.Marshal(coin)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"algorithm":"FancyHash"`)
+	assert.Contains(t, string(out), `"family":"newfamily"`)
+}
@@ -0,0 +1,82 @@
+package miningcore
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// unmarshalLenient decodes data into the struct v points to like json.Unmarshal,
+// but also accepts integers of v serialized as JSON strings, as some coins and proxies do.
+// v must not implement json.Unmarshaler, use a type definition of the model to strip its methods.
+func unmarshalLenient(data []byte, v any) error {
+	data, err := unquoteInts(data, reflect.TypeOf(v).Elem())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unquoteInts replaces the string values of the integer fields of the struct type t in the JSON object data
+// with the numbers they hold, including the fields promoted from embedded structs.
+// Values that are no numbers are left as they are, so decoding reports them.
+// An empty string is treated like null.
+func unquoteInts(data []byte, t reflect.Type) ([]byte, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil || raw == nil {
+		// not an object, let the decoder report it
+		return data, nil // nolint:nilerr
+	}
+	changed := false
+	for _, f := range reflect.VisibleFields(t) {
+		if f.Anonymous || !f.IsExported() || !isInt(f.Type.Kind()) {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		// keys match field names case-insensitively, like they do for encoding/json
+		for key, v := range raw {
+			if !strings.EqualFold(key, name) || len(v) == 0 || v[0] != '"' {
+				continue
+			}
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				return nil, err
+			}
+			s = strings.TrimSpace(s)
+			switch {
+			case s == "":
+				raw[key] = json.RawMessage("null")
+			case isNumber(s):
+				raw[key] = json.RawMessage(s)
+			default:
+				continue
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return data, nil
+	}
+	return json.Marshal(raw)
+}
+
+func isInt(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isNumber reports whether s is a valid JSON number.
+func isNumber(s string) bool {
+	return (s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) && json.Valid([]byte(s))
+}
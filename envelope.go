@@ -0,0 +1,37 @@
+package miningcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// unwrapEnvelope returns the payload of responses that miningcore wraps in an envelope with the
+// success and responseMessageType fields and the payload in the response field. Other responses
+// are returned as they are.
+// Responses reporting "success": false are returned as APIError wrapping ErrUnsuccessfulResponse,
+// even though their status is 200.
+func unwrapEnvelope(endpoint string, body []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return body, nil
+	}
+	var env struct {
+		Success             *bool           `json:"success"`
+		ResponseMessageType json.RawMessage `json:"responseMessageType"`
+		Response            json.RawMessage `json:"response"`
+	}
+	if err := json.Unmarshal(trimmed, &env); err != nil {
+		// let the decoder of the model report it
+		return body, nil // nolint:nilerr
+	}
+	if env.Success != nil && !*env.Success {
+		e := newAPIError(endpoint, http.StatusOK, body)
+		e.Err = ErrUnsuccessfulResponse
+		return nil, e
+	}
+	if env.Response != nil && (env.Success != nil || env.ResponseMessageType != nil) {
+		return env.Response, nil
+	}
+	return body, nil
+}
@@ -0,0 +1,68 @@
+package miningcore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	// enveloped and bare responses decode the same
+	for _, b := range []string{
+		`{"success": true, "responseMessageType": 0, "response": {"paymentThreshold": 0.5}}`,
+		`{"responseMessageType": 0, "response": {"paymentThreshold": 0.5}}`,
+		`{"paymentThreshold": 0.5}`,
+	} {
+		body = b
+		settings, code, err := client.GetMinerSettings(ctx, "eth", testMiner)
+		assert.NoError(t, err, b)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 0.5, settings.PaymentThreshold, b)
+	}
+
+	// a field named response is only unwrapped inside an envelope
+	body = `{"response": {"paymentThreshold": 1}, "paymentThreshold": 0.5}`
+	settings, _, err := client.GetMinerSettings(ctx, "eth", testMiner)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, settings.PaymentThreshold)
+
+	// paginated responses keep their meta
+	body = `{"pageCount": 2, "success": true, "result": [{"blockHeight": 1}]}`
+	blocks, _, err := client.GetPoolBlocks(ctx, "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), blocks.PageCount)
+	assert.Equal(t, 1, len(blocks.Result))
+}
+
+func TestEnvelopeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success": false, "responseMessageType": 1, "responseMessageId": "invalidAddress", "response": null}`))
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	_, code, err := client.GetMinerSettings(ctx, "eth", testMiner)
+	assert.ErrorIs(t, err, ErrUnsuccessfulResponse)
+	assert.Equal(t, http.StatusOK, code)
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusOK, apiErr.StatusCode)
+	assert.Equal(t, ResourceMiner, apiErr.Resource)
+	assert.Contains(t, apiErr.Error(), "invalidAddress")
+
+	// responses that are not decoded are checked as well
+	_, err = client.AddBalance(ctx, "eth", &BalanceAdjustment{Address: testMiner, Amount: 1})
+	assert.ErrorIs(t, err, ErrUnsuccessfulResponse)
+}
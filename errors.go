@@ -0,0 +1,243 @@
+package miningcore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrEndpointUnsupported is wrapped by the APIError of optional endpoints that don't exist on the server,
+// typically because it runs an older miningcore version. It can be checked with errors.Is.
+//
+// The optional endpoints are the miner performance, daily earnings and admin pools endpoints,
+// used by GetMinerPerformance, GetMinerDailyEarnings, GetMinerEarningsSeries and GetAdminPools.
+// miningcore answers requests for unknown resources with a JSON message, a 404 with an empty
+// or non-JSON body therefore means the route itself is missing.
+var ErrEndpointUnsupported = errors.New("miningcore: endpoint not supported by the server")
+
+// ErrInvalidPathSegment is returned if a pool ID or miner address can't be used in a request path,
+// because it is empty or a relative path segment.
+var ErrInvalidPathSegment = errors.New("miningcore: invalid path segment")
+
+// ErrUnsuccessfulResponse is wrapped by the APIError of responses with a 200 status that report
+// "success": false, which miningcore sends for some failures.
+var ErrUnsuccessfulResponse = errors.New("miningcore: server reported an unsuccessful response")
+
+// ErrTooManyPages is returned by helpers that page through all results of an endpoint if the result has more
+// pages than they read, so a total over the pages read would be incomplete.
+var ErrTooManyPages = errors.New("miningcore: too many pages")
+
+// ErrServiceUnavailable is wrapped by the APIError of 503 responses, which pools send while they are paused
+// for maintenance. Clients should back off, APIError.RetryAfter holds the delay requested by the server, if any.
+var ErrServiceUnavailable = errors.New("miningcore: service unavailable")
+
+// Resource describes the kind of resource a request path refers to.
+type Resource int
+
+const (
+	// ResourceUnknown is used for paths that don't refer to a pool or miner.
+	ResourceUnknown Resource = iota
+	// ResourcePool is used for paths below /pools/{id}.
+	ResourcePool
+	// ResourceMiner is used for paths below /pools/{id}/miners/{address}.
+	ResourceMiner
+)
+
+// APIError is returned when the API responds with an unexpected status code.
+type APIError struct {
+	StatusCode int
+	Endpoint   string
+	Body       []byte
+	// Resource is the kind of resource the endpoint refers to, used to tell apart an unknown pool from an unknown miner.
+	Resource Resource
+	// Err is the sentinel error describing the failure, if there is a more specific one.
+	Err error
+	// RetryAfter is the delay requested by the Retry-After header of the response, 0 if it had none.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if len(e.Body) == 0 && e.Err != nil {
+		return fmt.Sprintf("%s: %s", e.Err, e.Endpoint)
+	}
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("miningcore: %s returned status %d", e.Endpoint, e.StatusCode)
+	}
+	return string(e.Body)
+}
+
+// Unwrap returns the sentinel error of e, if any.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// newAPIError creates an APIError for a response of the given endpoint.
+func newAPIError(endpoint string, status int, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: status,
+		Endpoint:   endpoint,
+		Body:       body,
+		Resource:   resourceOf(endpoint),
+	}
+	switch {
+	case status == http.StatusNotFound && !json.Valid(bytes.TrimSpace(body)) && isOptionalEndpoint(endpoint):
+		e.Err = ErrEndpointUnsupported
+	case status == http.StatusServiceUnavailable:
+		e.Err = ErrServiceUnavailable
+	}
+	return e
+}
+
+// responseError creates an APIError for a response with an unexpected status and the body read from it.
+func responseError(endpoint string, resp *http.Response, body []byte) *APIError {
+	e := newAPIError(endpoint, resp.StatusCode, body)
+	e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return e
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as HTTP date relative to now.
+// Invalid values and dates in the past yield 0.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	t, err := http.ParseTime(v)
+	if err != nil || !t.After(now) {
+		return 0
+	}
+	return t.Sub(now)
+}
+
+// isOptionalEndpoint reports whether the endpoint is missing on some miningcore versions.
+func isOptionalEndpoint(endpoint string) bool {
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	switch {
+	case endpoint == "/api/admin/pools":
+		return true
+	case resourceOf(endpoint) != ResourceMiner:
+		return false
+	case segments[len(segments)-1] == "performance":
+		return true
+	case len(segments) > 1 && segments[len(segments)-2] == "earnings" && segments[len(segments)-1] == "daily":
+		return true
+	}
+	return false
+}
+
+// resourceOf infers the resource from a request path like /api/v2/pools/{id}/miners/{address}/payments.
+func resourceOf(endpoint string) Resource {
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	for i, s := range segments {
+		if s != "pools" {
+			continue
+		}
+		switch {
+		case len(segments) > i+3 && segments[i+2] == "miners":
+			return ResourceMiner
+		case len(segments) > i+1:
+			return ResourcePool
+		}
+	}
+	return ResourceUnknown
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsServiceUnavailable reports whether err is an APIError with status 503, usually because the pool is paused
+// for maintenance. See ErrServiceUnavailable.
+func IsServiceUnavailable(err error) bool {
+	return errors.Is(err, ErrServiceUnavailable)
+}
+
+// IsUnauthorized reports whether err is an APIError with status 401 or 403,
+// which the admin API returns for clients that are not allowed to use it.
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// IsPoolNotFound reports whether err is a 404 of a pool endpoint, which means the pool id is unknown.
+func IsPoolNotFound(err error) bool {
+	return isNotFound(err, ResourcePool)
+}
+
+// IsMinerNotFound reports whether err is a 404 of a miner endpoint, which usually means the miner address is unknown.
+func IsMinerNotFound(err error) bool {
+	return isNotFound(err, ResourceMiner)
+}
+
+func isNotFound(err error, r Resource) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && apiErr.Resource == r && apiErr.Err == nil
+}
+
+// Capabilities lists which optional endpoints the server supports.
+type Capabilities struct {
+	MinerPerformance bool
+	DailyEarnings    bool
+	AdminPools       bool
+}
+
+// DetectCapabilities probes the optional endpoints of the server, see ErrEndpointUnsupported.
+// The miner endpoints are probed using the first pool and its pool address, so the server should have
+// at least one pool configured. Endpoints that reject the request, e.g. admin endpoints without
+// the required access, still count as supported.
+func (c *Client) DetectCapabilities(ctx context.Context) (Capabilities, error) {
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	var caps Capabilities
+	pools, _, err := c.GetPools(ctx)
+	if err != nil {
+		return caps, err
+	}
+
+	probe := func(err error) (bool, error) {
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, ErrEndpointUnsupported):
+			return false, nil
+		case errors.As(err, &apiErr):
+			return true, nil
+		default:
+			return false, err
+		}
+	}
+
+	_, _, err = c.GetAdminPools(ctx)
+	if caps.AdminPools, err = probe(err); err != nil {
+		return caps, err
+	}
+	if len(pools) == 0 {
+		return caps, nil
+	}
+
+	id, addr := pools[0].ID, pools[0].Address
+	_, _, err = c.GetMinerPerformance(ctx, id, addr)
+	if caps.MinerPerformance, err = probe(err); err != nil {
+		return caps, err
+	}
+	_, _, err = c.GetMinerDailyEarnings(ctx, id, addr)
+	if caps.DailyEarnings, err = probe(err); err != nil {
+		return caps, err
+	}
+	return caps, nil
+}
@@ -0,0 +1,143 @@
+package miningcore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotFoundErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	_, code, err := client.GetPool(ctx, "nope")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.True(t, IsNotFound(err))
+	assert.True(t, IsPoolNotFound(err))
+	assert.False(t, IsMinerNotFound(err))
+
+	_, _, err = client.GetPoolBlocks(ctx, "nope")
+	assert.True(t, IsPoolNotFound(err))
+
+	_, _, err = client.GetMiner(ctx, "eth", "nope")
+	assert.True(t, IsMinerNotFound(err))
+	assert.False(t, IsPoolNotFound(err))
+
+	_, _, err = client.GetMinerPayments(ctx, "eth", "nope")
+	assert.True(t, IsMinerNotFound(err))
+
+	_, _, err = client.GetPools(ctx)
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsPoolNotFound(err))
+	assert.False(t, IsMinerNotFound(err))
+}
+
+func TestAPIError(t *testing.T) {
+	_, code, err := newClient().GetPool(context.Background(), "mock")
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "/api/pools/mock", apiErr.Endpoint)
+	assert.False(t, IsNotFound(err))
+	assert.EqualError(t, err, "miningcore: /api/pools/mock returned status 403")
+}
+
+func TestEndpointUnsupported(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/eth/miners/"+testMiner, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "miner not found"}`))
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	_, code, err := client.GetMinerPerformance(ctx, "eth", testMiner)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.ErrorIs(t, err, ErrEndpointUnsupported)
+	assert.False(t, IsMinerNotFound(err))
+
+	_, _, err = client.GetMinerDailyEarnings(ctx, "eth", testMiner)
+	assert.ErrorIs(t, err, ErrEndpointUnsupported)
+
+	_, _, err = client.GetAdminPools(ctx)
+	assert.ErrorIs(t, err, ErrEndpointUnsupported)
+
+	// required endpoints and resources that are not found are not reported as unsupported
+	_, _, err = client.GetMiner(ctx, "eth", testMiner)
+	assert.NotErrorIs(t, err, ErrEndpointUnsupported)
+	assert.True(t, IsMinerNotFound(err))
+	_, _, err = client.GetPoolBlocks(ctx, "eth")
+	assert.NotErrorIs(t, err, ErrEndpointUnsupported)
+}
+
+func TestServiceUnavailable(t *testing.T) {
+	var retryAfter string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("pool is paused."))
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	_, code, err := client.GetPool(ctx, "eth")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, IsServiceUnavailable(err))
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	assert.EqualError(t, err, "pool is paused.")
+	assert.False(t, IsPoolNotFound(err))
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, time.Duration(0), apiErr.RetryAfter)
+
+	retryAfter = "120"
+	err = client.StreamPoolBlocks(ctx, "eth", func(*Block) error { return nil })
+	assert.True(t, IsServiceUnavailable(err))
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 2*time.Minute, apiErr.RetryAfter)
+
+	assert.False(t, IsServiceUnavailable(&APIError{StatusCode: http.StatusInternalServerError}))
+	assert.False(t, IsServiceUnavailable(context.Canceled))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter("Mon, 01 May 2023 12:01:30 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("Mon, 01 May 2023 11:00:00 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-5", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+}
+
+func TestDetectCapabilities(t *testing.T) {
+	caps, err := newClient().DetectCapabilities(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, Capabilities{DailyEarnings: true}, caps)
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools", poolsReq)
+	handler.HandleFunc("/api/admin/pools", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	handler.HandleFunc("/api/pools/eth/miners/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	caps, err = New(srv.URL).DetectCapabilities(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, Capabilities{MinerPerformance: true, AdminPools: true}, caps)
+}
@@ -0,0 +1,258 @@
+package miningcore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotEventStream is returned by StreamPoolEvents if the server doesn't answer with a server-sent events stream,
+// typically because neither miningcore nor a proxy in front of it provides one.
+var ErrNotEventStream = errors.New("miningcore: server did not respond with an event stream")
+
+const (
+	// eventsReconnectDelay is the initial delay before reconnecting to an event stream, if the server doesn't set one.
+	eventsReconnectDelay = time.Second
+	// minEventsReconnectDelay is the lower bound of a retry interval set by the server,
+	// so a server sending "retry: 0" can't make the client reconnect in a tight loop.
+	minEventsReconnectDelay = 100 * time.Millisecond
+	// maxEventsReconnectDelay caps the delay between reconnects.
+	maxEventsReconnectDelay = 30 * time.Second
+	// maxEventSize is the maximum size of a single line of an event stream.
+	maxEventSize = 1 << 20
+)
+
+// PoolEvent is a live event of a pool received from a server-sent events stream.
+type PoolEvent struct {
+	// ID is the event ID set by the server, if any.
+	ID string
+	// Type is the event type, such as WsBlockFound. If the server doesn't name the event,
+	// it is taken from the type field of the data, like in miningcore notifications.
+	Type WebsocketMsg
+	// Data is the payload of the event, usually JSON.
+	Data []byte
+	// Err is set on the last event if the stream could not be reconnected, the other fields are zero then.
+	Err error
+}
+
+// Decode decodes the JSON data of the event into v, for example a BlockFoundMessage.
+func (e PoolEvent) Decode(v any) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// StreamPoolEvents opens a server-sent events stream of live events of a pool at /api/pools/{id}/events, which
+// is offered by some deployments and proxies, and emits its events until ctx is canceled. The channel is closed then.
+//
+// ErrNotEventStream is returned if the server responds with anything but an event stream, and an APIError
+// for statuses other than 200. If the connection drops, the stream is reopened with increasing delays,
+// starting at the retry interval set by the server but no less than 100ms, and resumed from the last event ID.
+// The delays only start over once an event was received, and last at least as long as the Retry-After
+// header of a failed reconnect asks. If reopening fails
+// with a status other than 429 or 5xx, the server stops sending an event stream or sends a line
+// larger than 1 MiB, a final event with Err set is emitted.
+//
+// The stream isn't subject to the request timeout and doesn't count towards WithMaxConcurrentRequests.
+func (c *Client) StreamPoolEvents(ctx context.Context, id string) (<-chan PoolEvent, error) {
+	e, err := endpoint("/api/pools/%s/events", id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.openEventStream(ctx, e, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan PoolEvent)
+	go func() {
+		defer close(ch)
+		s := &eventStream{ch: ch, delay: eventsReconnectDelay}
+		for {
+			err := s.read(ctx, body)
+			_ = body.Close()
+
+			if err == nil {
+				body, err = s.reconnect(ctx, c, e)
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					select {
+					case ch <- PoolEvent{Err: err}:
+					case <-ctx.Done():
+					}
+				}
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// openEventStream requests an event stream, resuming after lastID if set.
+func (c *Client) openEventStream(ctx context.Context, endpoint, lastID string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, endpoint, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	// the stream stays open, so it must not be limited by the request timeout
+	client := &http.Client{Transport: c.http.Transport, CheckRedirect: c.http.CheckRedirect}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	c.updateRateLimit(resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		defer drainAndClose(resp.Body)
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxDrainBytes))
+		if err != nil {
+			return nil, err
+		}
+		return nil, responseError(endpoint, resp, body)
+	}
+	ct := resp.Header.Get("Content-Type")
+	if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != "text/event-stream" {
+		drainAndClose(resp.Body)
+		return nil, fmt.Errorf("%w: content type %q", ErrNotEventStream, ct)
+	}
+	return resp.Body, nil
+}
+
+// eventStream holds the state of an event stream across reconnects.
+type eventStream struct {
+	ch     chan<- PoolEvent
+	lastID string
+	// delay is the reconnect delay set by the server or the default.
+	delay time.Duration
+	// failures counts the reconnects since the last event was received, so a server that accepts
+	// connections and drops them right away is backed off from as well.
+	failures int
+}
+
+// reconnect reopens the stream, waiting between attempts, until it succeeds, ctx is canceled
+// or the error is not transient.
+func (s *eventStream) reconnect(ctx context.Context, c *Client, endpoint string) (io.ReadCloser, error) {
+	var retryAfter time.Duration
+	for {
+		s.failures++
+		wait := exponentialBackoff(s.failures, s.delay)
+		if wait > maxEventsReconnectDelay {
+			wait = maxEventsReconnectDelay
+		}
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		body, err := c.openEventStream(ctx, endpoint, s.lastID)
+		if err == nil {
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var apiErr *APIError
+		if errors.Is(err, ErrNotEventStream) || (errors.As(err, &apiErr) && apiErr.StatusCode != http.StatusTooManyRequests && apiErr.StatusCode < 500) {
+			return nil, err
+		}
+		retryAfter = 0
+		if apiErr != nil {
+			retryAfter = apiErr.RetryAfter
+		}
+	}
+}
+
+// read parses events from r and emits them until r ends or fails.
+// Events that are cut off by the end of the stream are discarded, as the spec requires, and so are their IDs.
+// A dropped connection is not an error, since the stream can be resumed, but a line longer than maxEventSize
+// is: the server would send the same event again after reconnecting.
+func (s *eventStream) read(ctx context.Context, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventSize)
+
+	var (
+		ev   PoolEvent
+		data []string
+		// id is the last event ID buffer of the spec, it becomes the last event ID once the event is dispatched
+		id = s.lastID
+	)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			// a blank line dispatches the event
+			s.lastID = id
+			if len(data) > 0 {
+				ev.ID = s.lastID
+				ev.Data = []byte(strings.Join(data, "\n"))
+				if ev.Type == "" || ev.Type == "message" {
+					ev.Type = eventType(ev.Data)
+				}
+				select {
+				case s.ch <- ev:
+					s.failures = 0
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			ev, data = PoolEvent{}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			ev.Type = WebsocketMsg(value)
+		case "data":
+			data = append(data, value)
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				id = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.delay = time.Duration(ms) * time.Millisecond
+				if s.delay < minEventsReconnectDelay {
+					s.delay = minEventsReconnectDelay
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		return fmt.Errorf("miningcore: event stream line exceeds %d bytes: %w", maxEventSize, err)
+	}
+	return nil
+}
+
+// eventType returns the type field of a JSON event payload, or an empty type.
+func eventType(data []byte) WebsocketMsg {
+	var msg RawMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return ""
+	}
+	return WebsocketMsg(msg.Type)
+}
@@ -0,0 +1,187 @@
+package miningcore
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamPoolEvents(t *testing.T) {
+	var (
+		connections int32
+		lastIDs     = make(chan string, 2)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/pools/eth/events", r.URL.Path)
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			fmt.Fprint(w, "retry: 10\n: keep-alive\n\n")
+			fmt.Fprint(w, "id: 1\ndata: {\"type\": \"blockfound\", \"poolId\": \"eth\", \"blockHeight\": 5}\n\n")
+			fmt.Fprint(w, "id: 2\r\nevent: payment\r\ndata: {\"poolId\": \"eth\",\r\ndata: \"amount\": 1.5}\r\n\r\n")
+			fmt.Fprint(w, "id: 99\ndata: cut off")
+		case 2:
+			lastIDs <- r.Header.Get("Last-Event-ID")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			lastIDs <- r.Header.Get("Last-Event-ID")
+			fmt.Fprint(w, "id: 3\ndata:plain text\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := New(srv.URL).StreamPoolEvents(ctx, "eth")
+	assert.NoError(t, err)
+
+	ev := <-events
+	assert.NoError(t, ev.Err)
+	assert.Equal(t, "1", ev.ID)
+	assert.Equal(t, WsBlockFound, ev.Type)
+	var block BlockFoundMessage
+	assert.NoError(t, ev.Decode(&block))
+	assert.Equal(t, uint64(5), block.BlockHeight)
+
+	ev = <-events
+	assert.Equal(t, "2", ev.ID)
+	assert.Equal(t, WsPayment, ev.Type)
+	assert.Equal(t, "{\"poolId\": \"eth\",\n\"amount\": 1.5}", string(ev.Data))
+
+	// the cut off event is dropped and the stream resumes after the last complete event
+	ev = <-events
+	assert.Equal(t, "3", ev.ID)
+	assert.Equal(t, "plain text", string(ev.Data))
+	assert.Equal(t, WebsocketMsg(""), ev.Type)
+	assert.Equal(t, "2", <-lastIDs)
+	assert.Equal(t, "2", <-lastIDs)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestStreamPoolEventsErrors(t *testing.T) {
+	var connections int32
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/json/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pool": {}}`))
+	})
+	handler.HandleFunc("/api/pools/gone/events", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&connections, 1) > 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "retry: 1\ndata: {}\n\n")
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	_, err := client.StreamPoolEvents(ctx, "json")
+	assert.ErrorIs(t, err, ErrNotEventStream)
+
+	_, err = client.StreamPoolEvents(ctx, "missing")
+	assert.True(t, IsNotFound(err))
+
+	events, err := client.StreamPoolEvents(ctx, "gone")
+	assert.NoError(t, err)
+	ev := <-events
+	assert.NoError(t, ev.Err)
+	assert.Equal(t, "{}", string(ev.Data))
+	ev = <-events
+	assert.True(t, IsNotFound(ev.Err))
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestStreamPoolEventsReconnectBackoff(t *testing.T) {
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		// a retry interval of 0 and connections dropped before any event
+		fmt.Fprint(w, "retry: 0\n\n")
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the delays are bounded below and keep growing across reconnects: 100ms, 200ms, 400ms
+	events, err := New(srv.URL).StreamPoolEvents(ctx, "eth")
+	assert.NoError(t, err)
+	time.Sleep(500 * time.Millisecond)
+	n := atomic.LoadInt32(&connections)
+	assert.GreaterOrEqual(t, n, int32(2))
+	assert.LessOrEqual(t, n, int32(4))
+	cancel()
+	for range events {
+	}
+}
+
+func TestStreamPoolEventsLineTooLong(t *testing.T) {
+	var connections int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connections, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: "+strings.Repeat("x", maxEventSize)+"\n\n")
+	}))
+	defer srv.Close()
+
+	// an event too large to read ends the stream instead of being requested again and again
+	events, err := New(srv.URL).StreamPoolEvents(context.Background(), "eth")
+	assert.NoError(t, err)
+	ev := <-events
+	assert.ErrorIs(t, ev.Err, bufio.ErrTooLong)
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&connections))
+}
+
+func TestStreamPoolEventsReconnectRetryAfter(t *testing.T) {
+	var (
+		connections int32
+		reconnected = make(chan time.Time, 1)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "retry: 1\ndata: {}\n\n")
+		case 2:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			reconnected <- time.Now()
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := New(srv.URL).StreamPoolEvents(ctx, "eth")
+	assert.NoError(t, err)
+	<-events
+	failed := time.Now()
+	// the reconnect after the 503 waits for the Retry-After instead of the short retry interval
+	assert.GreaterOrEqual(t, (<-reconnected).Sub(failed), 900*time.Millisecond)
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,87 @@
+package miningcore
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	// paymentsPageSize is the page size used when paging through the payments of a miner.
+	paymentsPageSize = 100
+	// maxPaymentPages bounds the number of pages of payments exported.
+	maxPaymentPages = 1000
+)
+
+// ExportMinerPaymentsCSV writes all payments of a miner to w as CSV with a header row and the columns
+// date, amount, txid and explorer_link, newest payments first. Pages of payments are streamed from the server
+// and written one after another, so the payments are never held in memory at once.
+//
+// Dates are formatted as RFC 3339 in the time zone of the client. Amounts are converted to whole coins
+// if the coin of the pool has Decimals, and links are the transaction links of the pool or built from
+// the ExplorerTxLink of the coin, see Payment.Receipt. The pool API reports neither, use
+// ExportMinerPaymentsCSVWithCoin to set them. Payments made while the export runs may shift the pages,
+// so a payment can appear twice. Paging stops at the last page reported by the server. A miner with more than
+// maxPaymentPages pages of payments fails with ErrTooManyPages after those pages were written.
+func (c *Client) ExportMinerPaymentsCSV(ctx context.Context, id, addr string, w io.Writer) error {
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	pool, _, err := c.GetPool(ctx, id)
+	if err != nil {
+		return err
+	}
+	var coin Coin
+	if pool.Coin != nil {
+		coin = *pool.Coin
+	}
+	return c.exportMinerPayments(ctx, id, addr, coin, w)
+}
+
+// ExportMinerPaymentsCSVWithCoin is ExportMinerPaymentsCSV with the coin used to convert amounts and build links
+// given by the caller, for example with Decimals and ExplorerTxLink set, instead of the coin reported by the pool.
+func (c *Client) ExportMinerPaymentsCSVWithCoin(ctx context.Context, id, addr string, coin Coin, w io.Writer) error {
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	return c.exportMinerPayments(ctx, id, addr, coin, w)
+}
+
+// exportMinerPayments writes the payments of a miner as CSV with amounts and links of coin.
+func (c *Client) exportMinerPayments(ctx context.Context, id, addr string, coin Coin, w io.Writer) error {
+	pageSize := c.clampPageSize(paymentsPageSize)
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"date", "amount", "txid", "explorer_link"}); err != nil {
+		return err
+	}
+	for page := 0; page < maxPaymentPages; page++ {
+		var (
+			n    int
+			meta Meta
+		)
+		err := c.streamMinerPayments(ctx, id, addr, func(p *Payment) error {
+			n++
+			r := c.NormalizePayment(coin, *p).Receipt(coin)
+			date := p.Created
+			if t, err := c.ParseTime(p.Created); err == nil {
+				date = t.Format(time.RFC3339)
+			}
+			return cw.Write([]string{date, strconv.FormatFloat(float64(r.Amount), 'f', -1, 64), r.TxID, r.TxURL})
+		}, &meta, map[string]string{
+			"page":     strconv.Itoa(page),
+			"pageSize": strconv.Itoa(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		if n < pageSize || (meta.PageCount > 0 && int64(page+1) >= meta.PageCount) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: miner %s has more than %d pages of payments", ErrTooManyPages, addr, maxPaymentPages)
+}
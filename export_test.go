@@ -0,0 +1,118 @@
+package miningcore
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportMinerPaymentsCSV(t *testing.T) {
+	const total = 250
+	var requests int
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/btc", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pool": {"id": "btc", "coin": {"symbol": "BTC", "decimals": 8, "explorerTxLink": "https://explorer.example/tx/{0}"}}}`))
+	})
+	handler.HandleFunc("/api/v2/pools/btc/miners/"+testMiner+"/payments", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		res := PaymentRes{Meta: &Meta{PageCount: (total + int64(pageSize) - 1) / int64(pageSize), Success: true}, Result: []*Payment{}}
+		for i := page * pageSize; i < total && i < (page+1)*pageSize; i++ {
+			p := &Payment{
+				Amount:                      float64(100_000_000 + i),
+				TransactionConfirmationData: fmt.Sprintf("tx%d", i),
+				Created:                     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Duration(i) * time.Hour).Format(time.RFC3339),
+			}
+			if i == 0 {
+				p.TransactionInfoLink = "https://pool.example/tx0"
+			}
+			res.Result = append(res.Result, p)
+		}
+		json.NewEncoder(w).Encode(res) // nolint:errcheck
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	var buf strings.Builder
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	err = New(srv.URL, WithTimeZone(berlin)).ExportMinerPaymentsCSV(context.Background(), "btc", testMiner, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, requests)
+
+	rows, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, total+1, len(rows))
+	assert.Equal(t, []string{"date", "amount", "txid", "explorer_link"}, rows[0])
+	assert.Equal(t, []string{"2023-01-01T01:00:00+01:00", "1", "tx0", "https://pool.example/tx0"}, rows[1])
+	assert.Equal(t, []string{"2023-01-01T00:00:00+01:00", "1.00000001", "tx1", "https://explorer.example/tx/tx1"}, rows[2])
+	assert.Equal(t, "tx249", rows[total][2])
+
+	// a page size that divides the payments stops at the last page reported, without requesting an empty page
+	requests = 0
+	buf.Reset()
+	err = New(srv.URL, WithMaxPageSize(50)).ExportMinerPaymentsCSV(context.Background(), "btc", testMiner, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, requests)
+	assert.Equal(t, total+1, strings.Count(buf.String(), "\n"))
+
+	// the coin of the caller overrides the coin of the pool
+	buf.Reset()
+	coin := Coin{Symbol: "BTC", Decimals: 2, ExplorerTxLink: "https://other.example/{0}"}
+	err = New(srv.URL).ExportMinerPaymentsCSVWithCoin(context.Background(), "btc", testMiner, coin, &buf)
+	assert.NoError(t, err)
+	rows, err = csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1000000.01", "tx1", "https://other.example/tx1"}, rows[2][1:])
+
+	err = New(srv.URL).ExportMinerPaymentsCSV(context.Background(), "unknown", testMiner, &buf)
+	assert.Error(t, err)
+}
+
+func TestExportMinerPaymentsCSVIgnoredPage(t *testing.T) {
+	var (
+		requests  int
+		pageCount = 2
+	)
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/btc", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pool": {"id": "btc"}}`))
+	})
+	// a server that ignores the page parameter returns the same full page for every page
+	handler.HandleFunc("/api/v2/pools/btc/miners/"+testMiner+"/payments", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		payments := make([]*Payment, 10)
+		for i := range payments {
+			payments[i] = &Payment{Amount: float64(i), Created: "2023-01-01T00:00:00Z"}
+		}
+		result, _ := json.Marshal(payments)
+		// the page count follows the result
+		fmt.Fprintf(w, `{"result": %s, "success": true, "pageCount": "%d"}`, result, pageCount)
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	var buf strings.Builder
+	err := New(srv.URL, WithMaxPageSize(10)).ExportMinerPaymentsCSV(context.Background(), "btc", testMiner, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 21, strings.Count(buf.String(), "\n"))
+
+	// without a page count the export fails once the page cap cuts it off
+	requests, pageCount = 0, 0
+	buf.Reset()
+	err = New(srv.URL, WithMaxPageSize(10)).ExportMinerPaymentsCSV(context.Background(), "btc", testMiner, &buf)
+	assert.ErrorIs(t, err, ErrTooManyPages)
+	assert.Equal(t, maxPaymentPages, requests)
+	assert.Equal(t, 10*maxPaymentPages+1, strings.Count(buf.String(), "\n"))
+}
@@ -2,8 +2,12 @@ package miningcore
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // GetPools returns a list of all available pools.
@@ -20,6 +24,26 @@ func (c *Client) GetPools(ctx context.Context) ([]*PoolInfo, int, error) {
 
 func (c *Client) UnmarshalPools(ctx context.Context, res any) (int, error) {
 	e := "/api/pools"
+	return c.doRequest(withCache(ctx), e, http.MethodGet, res, nil)
+}
+
+// GetAdminPools returns a list of all pools including admin-only operational fields.
+// The admin API is only reachable from addresses in miningcore's admin whitelist, or with the credentials
+// required by the proxy in front of it (see WithAuthToken). Use IsUnauthorized to check for a rejected request.
+// Servers without this endpoint return an error wrapping ErrEndpointUnsupported.
+func (c *Client) GetAdminPools(ctx context.Context) ([]*AdminPool, int, error) {
+	var res struct {
+		Pools []*AdminPool `json:"pools"`
+	}
+	s, err := c.UnmarshalAdminPools(ctx, &res)
+	if err != nil {
+		return nil, s, err
+	}
+	return res.Pools, s, nil
+}
+
+func (c *Client) UnmarshalAdminPools(ctx context.Context, res any) (int, error) {
+	e := "/api/admin/pools"
 	return c.doRequest(ctx, e, http.MethodGet, res, nil)
 }
 
@@ -35,13 +59,69 @@ func (c *Client) GetPool(ctx context.Context, id string) (*PoolInfo, int, error)
 	return &res.Pool, s, nil
 }
 
+// PoolExists reports whether a pool with the given id exists, without downloading the pool.
+// It sends a HEAD request and falls back to GET if the server doesn't support HEAD.
+// A 2xx status means the pool exists and a 404 that it doesn't. Any other status is returned as error.
+func (c *Client) PoolExists(ctx context.Context, id string) (bool, error) {
+	e, err := endpoint("/api/pools/%s", id)
+	if err != nil {
+		return false, err
+	}
+	s, err := c.doRequest(ctx, e, http.MethodHead, nil, nil)
+	if s == http.StatusMethodNotAllowed || s == http.StatusNotImplemented {
+		s, err = c.doRequest(ctx, e, http.MethodGet, nil, nil)
+	}
+	switch {
+	case s >= 200 && s < 300:
+		return true, nil
+	case s == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// GetPoolRaw returns the undecoded JSON of a specific pool.
+// This is useful to inspect fields of a coin that the PoolInfo model doesn't cover.
+func (c *Client) GetPoolRaw(ctx context.Context, id string) (json.RawMessage, int, error) {
+	var res struct {
+		Pool json.RawMessage `json:"pool"`
+	}
+	s, err := c.UnmarshalPool(ctx, id, &res)
+	if err != nil {
+		return nil, s, err
+	}
+	return res.Pool, s, nil
+}
+
 func (c *Client) UnmarshalPool(ctx context.Context, id string, res any) (int, error) {
-	e := "/api/pools/" + id
-	return c.doRequest(ctx, e, http.MethodGet, res, nil)
+	e, err := endpoint("/api/pools/%s", id)
+	if err != nil {
+		return 0, err
+	}
+	return c.doRequest(withCache(ctx), e, http.MethodGet, res, nil)
+}
+
+// GetPayoutScheme returns the payment processing configuration of a pool.
+func (c *Client) GetPayoutScheme(ctx context.Context, id string) (*PayoutScheme, int, error) {
+	pool, s, err := c.GetPool(ctx, id)
+	if err != nil {
+		return nil, s, err
+	}
+	if pool.PaymentProcessing == nil {
+		return &PayoutScheme{}, s, nil
+	}
+	pp := pool.PaymentProcessing
+	return &PayoutScheme{
+		Enabled:        pp.Enabled,
+		Scheme:         pp.PayoutScheme,
+		MinimumPayment: pp.MinimumPayment,
+		Config:         pp.PayoutSchemeConfig,
+	}, s, nil
 }
 
 // GetPoolBlocks returns a list of blocks found by a pool.
-// This endpoint implements pagination using the `page` and `perPage` parameters.
+// This endpoint implements pagination using the `page` and `pageSize` parameters.
 func (c *Client) GetPoolBlocks(ctx context.Context, id string, params ...map[string]string) (*BlocksRes, int, error) {
 	var res BlocksRes
 	s, err := c.UnmarshalPoolBlocks(ctx, id, &res, params...)
@@ -52,12 +132,65 @@ func (c *Client) GetPoolBlocks(ctx context.Context, id string, params ...map[str
 }
 
 func (c *Client) UnmarshalPoolBlocks(ctx context.Context, id string, res any, params ...map[string]string) (int, error) {
-	e := fmt.Sprintf("/api/v2/pools/%s/blocks", id)
-	return c.doRequest(ctx, e, http.MethodGet, res, nil, params...)
+	p, err := c.pageParams(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	e, err := endpoint("/api/v2/pools/%s/blocks", id)
+	if err != nil {
+		return 0, err
+	}
+	return c.doRequest(ctx, e, http.MethodGet, res, nil, p)
+}
+
+// pageCursorPrefix marks cursors that were derived from page numbers by the client.
+const pageCursorPrefix = "page:"
+
+// GetPoolBlocksCursor returns a page of blocks found by a pool together with the cursor of the next page.
+// Pass an empty cursor to fetch the first page. An empty next cursor means there are no more pages.
+//
+// If the server returns a `nextPageToken` in the response meta, it is used as the cursor and sent back
+// using the `pageToken` parameter, which keeps iteration stable while new blocks are found.
+// Servers without cursor support are iterated by page number instead.
+func (c *Client) GetPoolBlocksCursor(ctx context.Context, id, cursor string, pageSize int) ([]*Block, string, int, error) {
+	params := map[string]string{
+		"pageSize": strconv.Itoa(pageSize),
+	}
+	page := 0
+	byToken := cursor != "" && !strings.HasPrefix(cursor, pageCursorPrefix)
+	switch {
+	case byToken:
+		params["pageToken"] = cursor
+	case cursor != "":
+		n, err := strconv.Atoi(strings.TrimPrefix(cursor, pageCursorPrefix))
+		if err != nil || n < 0 {
+			return nil, "", 0, fmt.Errorf("miningcore: invalid cursor %q", cursor)
+		}
+		page = n
+	}
+	if !byToken {
+		params["page"] = strconv.Itoa(page)
+	}
+
+	res, s, err := c.GetPoolBlocks(ctx, id, params)
+	if err != nil {
+		return nil, "", s, err
+	}
+
+	var next string
+	if res.Meta != nil {
+		switch {
+		case res.NextPageToken != "":
+			next = res.NextPageToken
+		case !byToken && int64(page+1) < res.PageCount:
+			next = pageCursorPrefix + strconv.Itoa(page+1)
+		}
+	}
+	return res.Result, next, s, nil
 }
 
 // GetPoolPayments returns a list of payments made by a pool.
-// This endpoint implements pagination using the `page` and `perPage` parameters.
+// This endpoint implements pagination using the `page` and `pageSize` parameters.
 func (c *Client) GetPoolPayments(ctx context.Context, id string, params ...map[string]string) (*PaymentRes, int, error) {
 	var res PaymentRes
 	s, err := c.UnmarshalPoolPayments(ctx, id, &res, params...)
@@ -68,12 +201,19 @@ func (c *Client) GetPoolPayments(ctx context.Context, id string, params ...map[s
 }
 
 func (c *Client) UnmarshalPoolPayments(ctx context.Context, id string, res any, params ...map[string]string) (int, error) {
-	e := fmt.Sprintf("/api/v2/pools/%s/payments", id)
-	return c.doRequest(ctx, e, http.MethodGet, res, nil, params...)
+	p, err := c.pageParams(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	e, err := endpoint("/api/v2/pools/%s/payments", id)
+	if err != nil {
+		return 0, err
+	}
+	return c.doRequest(ctx, e, http.MethodGet, res, nil, p)
 }
 
 // GetMiners returns a list of all miners from a pool.
-// This endpoint implements pagination using the `page` and `perPage` parameters.
+// This endpoint implements pagination using the `page` and `pageSize` parameters.
 func (c *Client) GetMiners(ctx context.Context, id string, params ...map[string]string) ([]*MinerPerformanceStats, int, error) {
 	var res []*MinerPerformanceStats
 	s, err := c.UnmarshalMiners(ctx, id, &res, params...)
@@ -83,9 +223,40 @@ func (c *Client) GetMiners(ctx context.Context, id string, params ...map[string]
 	return res, s, nil
 }
 
+// GetMinersPage returns a page of miners from a pool together with the total number of miners.
+// The total is read from the `X-Total-Count` response header and is -1 if the server does not send it.
+// This endpoint implements pagination using the `page` and `pageSize` parameters.
+func (c *Client) GetMinersPage(ctx context.Context, id string, params ...map[string]string) (*Page[*MinerPerformanceStats], int, error) {
+	p, err := c.pageParams(ctx, params...)
+	if err != nil {
+		return nil, 0, err
+	}
+	var res []*MinerPerformanceStats
+	e, err := endpoint("/api/pools/%s/miners", id)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := c.do(ctx, e, http.MethodGet, &res, nil, p)
+	if err != nil {
+		return nil, statusCode(resp), err
+	}
+	page := &Page[*MinerPerformanceStats]{Items: res, Total: -1}
+	if total, err := strconv.ParseInt(resp.Header.Get("X-Total-Count"), 10, 64); err == nil && total >= 0 {
+		page.Total = total
+	}
+	return page, resp.StatusCode, nil
+}
+
 func (c *Client) UnmarshalMiners(ctx context.Context, id string, res any, params ...map[string]string) (int, error) {
-	e := fmt.Sprintf("/api/pools/%s/miners", id)
-	return c.doRequest(ctx, e, http.MethodGet, res, nil, params...)
+	p, err := c.pageParams(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	e, err := endpoint("/api/pools/%s/miners", id)
+	if err != nil {
+		return 0, err
+	}
+	return c.doRequest(ctx, e, http.MethodGet, res, nil, p)
 }
 
 // GetMiner returns information about a specific miner from a pool.
@@ -104,12 +275,15 @@ func (c *Client) GetMiner(ctx context.Context, id, addr string, params ...map[st
 }
 
 func (c *Client) UnmarshalMiner(ctx context.Context, id, addr string, res any, params ...map[string]string) (int, error) {
-	e := fmt.Sprintf("/api/pools/%s/miners/%s", id, addr)
+	e, err := endpoint("/api/pools/%s/miners/%s", id, c.address(addr))
+	if err != nil {
+		return 0, err
+	}
 	return c.doRequest(ctx, e, http.MethodGet, res, nil, params...)
 }
 
 // GetMinerPayments returns a list of payments of a miner.
-// This endpoint implements pagination using the `page` and `perPage` parameters.
+// This endpoint implements pagination using the `page` and `pageSize` parameters.
 func (c *Client) GetMinerPayments(ctx context.Context, id, addr string, params ...map[string]string) (*PaymentRes, int, error) {
 	var res PaymentRes
 	s, err := c.UnmarshalMinerPayments(ctx, id, addr, &res, params...)
@@ -120,12 +294,20 @@ func (c *Client) GetMinerPayments(ctx context.Context, id, addr string, params .
 }
 
 func (c *Client) UnmarshalMinerPayments(ctx context.Context, id, addr string, res any, params ...map[string]string) (int, error) {
-	e := fmt.Sprintf("/api/v2/pools/%s/miners/%s/payments", id, addr)
-	return c.doRequest(ctx, e, http.MethodGet, res, nil, params...)
+	p, err := c.pageParams(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	e, err := endpoint("/api/v2/pools/%s/miners/%s/payments", id, c.address(addr))
+	if err != nil {
+		return 0, err
+	}
+	return c.doRequest(ctx, e, http.MethodGet, res, nil, p)
 }
 
 // GetMinerDailyEarnings returns a list of daily earnings of a miner.
-// This endpoint implements pagination using the `page` and `perPage` parameters.
+// This endpoint implements pagination using the `page` and `pageSize` parameters.
+// Servers without this endpoint return an error wrapping ErrEndpointUnsupported.
 func (c *Client) GetMinerDailyEarnings(ctx context.Context, id, addr string, params ...map[string]string) (*DailyEarningRes, int, error) {
 	var res DailyEarningRes
 	s, err := c.UnmarshalMinerDailyEarnings(ctx, id, addr, &res, params...)
@@ -136,12 +318,19 @@ func (c *Client) GetMinerDailyEarnings(ctx context.Context, id, addr string, par
 }
 
 func (c *Client) UnmarshalMinerDailyEarnings(ctx context.Context, id, addr string, res any, params ...map[string]string) (int, error) {
-	e := fmt.Sprintf("/api/v2/pools/%s/miners/%s/earnings/daily", id, addr)
-	return c.doRequest(ctx, e, http.MethodGet, res, nil, params...)
+	p, err := c.pageParams(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	e, err := endpoint("/api/v2/pools/%s/miners/%s/earnings/daily", id, c.address(addr))
+	if err != nil {
+		return 0, err
+	}
+	return c.doRequest(ctx, e, http.MethodGet, res, nil, p)
 }
 
 // GetMinerBalanceChanges returns a list of balance changes of a miner.
-// This endpoint implements pagination using the `page` and `perPage` parameters.
+// This endpoint implements pagination using the `page` and `pageSize` parameters.
 func (c *Client) GetMinerBalanceChanges(ctx context.Context, id, addr string, params ...map[string]string) (*BalanceChangeRes, int, error) {
 	var res BalanceChangeRes
 	s, err := c.UnmarshalMinerBalanceChanges(ctx, id, addr, &res, params...)
@@ -152,8 +341,15 @@ func (c *Client) GetMinerBalanceChanges(ctx context.Context, id, addr string, pa
 }
 
 func (c *Client) UnmarshalMinerBalanceChanges(ctx context.Context, id, addr string, res any, params ...map[string]string) (int, error) {
-	e := fmt.Sprintf("/api/v2/pools/%s/miners/%s/balancechanges", id, addr)
-	return c.doRequest(ctx, e, http.MethodGet, res, nil, params...)
+	p, err := c.pageParams(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	e, err := endpoint("/api/v2/pools/%s/miners/%s/balancechanges", id, c.address(addr))
+	if err != nil {
+		return 0, err
+	}
+	return c.doRequest(ctx, e, http.MethodGet, res, nil, p)
 }
 
 // GetMinerPerformance returns a list of performance samples of a miner.
@@ -162,18 +358,22 @@ func (c *Client) UnmarshalMinerBalanceChanges(ctx context.Context, id, addr stri
 // 		"Hour"
 // 		"Day"
 // 		"Month"
+// Servers without this endpoint return an error wrapping ErrEndpointUnsupported.
 func (c *Client) GetMinerPerformance(ctx context.Context, id, addr string, params ...map[string]string) ([]*WorkerStats, int, error) {
 	var res []*WorkerStats
 	s, err := c.UnmarshalMinerPerformance(ctx, id, addr, &res, params...)
 	if err != nil {
 		return nil, s, err
 	}
-	return nil, 0, nil
+	return res, s, nil
 }
 
 func (c *Client) UnmarshalMinerPerformance(ctx context.Context, id, addr string, res any, params ...map[string]string) (int, error) {
-	e := fmt.Sprintf("/api/pools/%s/miners/%s/performance", id, addr)
-	return c.doRequest(ctx, e, http.MethodGet, res, nil)
+	e, err := endpoint("/api/pools/%s/miners/%s/performance", id, c.address(addr))
+	if err != nil {
+		return 0, err
+	}
+	return c.doRequest(ctx, e, http.MethodGet, res, nil, params...)
 }
 
 // GetMinerSettings returns the current miner settings of a pool.
@@ -187,14 +387,18 @@ func (c *Client) GetMinerSettings(ctx context.Context, id, addr string) (*MinerS
 }
 
 func (c *Client) UnmarshalMinerSettings(ctx context.Context, id, addr string, res any) (int, error) {
-	e := fmt.Sprintf("/api/pools/%s/miners/%s/settings", id, addr)
+	e, err := endpoint("/api/pools/%s/miners/%s/settings", id, c.address(addr))
+	if err != nil {
+		return 0, err
+	}
 	return c.doRequest(ctx, e, http.MethodGet, res, nil)
 }
 
 // PostMinerSettings updates the miner settings of a pool.
+// If a settings signer is set, the request is signed with it, see WithSettingsSigner.
 func (c *Client) PostMinerSettings(ctx context.Context, id, addr string, settings *MinerSettingsUpdateReq) (*MinerSettings, int, error) {
 	var res MinerSettings
-	s, err := c.UnmarshalMinerSettings(ctx, id, addr, &res)
+	s, err := c.UnmarshalPostMinerSettings(ctx, id, addr, settings, &res)
 	if err != nil {
 		return nil, s, err
 	}
@@ -202,7 +406,21 @@ func (c *Client) PostMinerSettings(ctx context.Context, id, addr string, setting
 }
 
 func (c *Client) UnmarshalPostMinerSettings(ctx context.Context, id, addr string, settings any, res any) (int, error) {
-	e := fmt.Sprintf("/api/pools/%s/miners/%s/settings", id, addr)
+	addr = c.address(addr)
+	if c.settingsSigner != nil {
+		ctx = withRequestHook(ctx, func(req *http.Request, body []byte) error {
+			sig, err := c.settingsSigner(id, addr, body)
+			if err != nil {
+				return fmt.Errorf("miningcore: failed to sign miner settings: %w", err)
+			}
+			req.Header.Set(SettingsSignatureHeader, sig)
+			return nil
+		})
+	}
+	e, err := endpoint("/api/pools/%s/miners/%s/settings", id, addr)
+	if err != nil {
+		return 0, err
+	}
 	return c.doRequest(ctx, e, http.MethodPost, res, settings)
 }
 
@@ -227,6 +445,39 @@ func (c *Client) GetPerformance(ctx context.Context, id string, params ...map[st
 }
 
 func (c *Client) UnmarshalPoolPerformance(ctx context.Context, id string, res any, params ...map[string]string) (int, error) {
-	e := fmt.Sprintf("/api/pools/%s/performance", id)
+	e, err := endpoint("/api/pools/%s/performance", id)
+	if err != nil {
+		return 0, err
+	}
 	return c.doRequest(ctx, e, http.MethodGet, res, nil, params...)
 }
+
+// GetNetworkHistory returns the network hashrate and difficulty over time, taken from the performance
+// samples of a pool and sorted from oldest to newest. Samples without network data are skipped.
+// The parameters are the same as for GetPerformance.
+func (c *Client) GetNetworkHistory(ctx context.Context, id string, params ...map[string]string) ([]*NetworkSample, int, error) {
+	stats, s, err := c.GetPerformance(ctx, id, params...)
+	if err != nil {
+		return nil, s, err
+	}
+	samples := make([]*NetworkSample, 0, len(stats))
+	for _, p := range stats {
+		if p == nil || (p.NetworkHashrate == 0 && p.NetworkDifficulty == 0) {
+			continue
+		}
+		samples = append(samples, &NetworkSample{
+			NetworkHashrate:   p.NetworkHashrate,
+			NetworkDifficulty: p.NetworkDifficulty,
+			Created:           p.Created,
+		})
+	}
+	sort.SliceStable(samples, func(i, j int) bool {
+		ti, erri := c.ParseTime(samples[i].Created)
+		tj, errj := c.ParseTime(samples[j].Created)
+		if erri != nil || errj != nil {
+			return samples[i].Created < samples[j].Created
+		}
+		return ti.Before(tj)
+	})
+	return samples, s, nil
+}
@@ -0,0 +1,289 @@
+package miningcore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Parts of a MinerDashboard, used as keys of MinerDashboard.Errors.
+const (
+	DashboardMiner    = "miner"
+	DashboardPayments = "payments"
+	DashboardEarnings = "earnings"
+)
+
+// MinerDashboard combines the data needed to display the details of a miner.
+type MinerDashboard struct {
+	Miner    *MinerStats
+	Payments *PaymentRes
+	Earnings *DailyEarningRes
+	// Errors holds the error of every part that could not be fetched.
+	Errors map[string]error
+}
+
+// GetMinerDashboard fetches the stats, the latest page of payments and the latest page of daily earnings
+// of a miner concurrently. Parts that fail are recorded in MinerDashboard.Errors while the others are still
+// returned. An error is only returned if all parts failed, e.g. because ctx was canceled.
+func (c *Client) GetMinerDashboard(ctx context.Context, id, addr string) (*MinerDashboard, error) {
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	var (
+		d  = &MinerDashboard{Errors: make(map[string]error)}
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	fail := func(part string, err error) {
+		mu.Lock()
+		d.Errors[part] = err
+		mu.Unlock()
+	}
+	firstPage := map[string]string{"page": "0"}
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		miner, _, err := c.GetMiner(ctx, id, addr)
+		if err != nil {
+			fail(DashboardMiner, err)
+			return
+		}
+		d.Miner = miner
+	}()
+	go func() {
+		defer wg.Done()
+		payments, _, err := c.GetMinerPayments(ctx, id, addr, firstPage)
+		if err != nil {
+			fail(DashboardPayments, err)
+			return
+		}
+		d.Payments = payments
+	}()
+	go func() {
+		defer wg.Done()
+		earnings, _, err := c.GetMinerDailyEarnings(ctx, id, addr, firstPage)
+		if err != nil {
+			fail(DashboardEarnings, err)
+			return
+		}
+		d.Earnings = earnings
+	}()
+	wg.Wait()
+
+	if len(d.Errors) == 3 {
+		return d, d.Errors[DashboardMiner]
+	}
+	return d, nil
+}
+
+// MinerPoolShare returns the fraction (0..1) of the pool hashrate contributed by a miner.
+// The miner and the pool are fetched concurrently, if one of the requests fails the other one is canceled.
+// The result is a point-in-time estimate based on the current hashrates, which fluctuate between samples.
+// If the pool reports no hashrate, 0 is returned.
+func (c *Client) MinerPoolShare(ctx context.Context, id, addr string) (float64, error) {
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	var (
+		miner *MinerStats
+		pool  *PoolInfo
+		fatal = firstError{cancel: cancel}
+		wg    sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		var err error
+		if miner, _, err = c.GetMiner(ctx, id, addr); err != nil {
+			fatal.set(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var err error
+		if pool, _, err = c.GetPool(ctx, id); err != nil {
+			fatal.set(err)
+		}
+	}()
+	wg.Wait()
+	if fatal.err != nil {
+		return 0, fatal.err
+	}
+
+	if pool.PoolStats == nil || pool.PoolStats.PoolHashrate <= 0 {
+		return 0, nil
+	}
+	share := miner.Hashrate() / float64(pool.PoolStats.PoolHashrate)
+	if share > 1 {
+		share = 1
+	}
+	return share, nil
+}
+
+const (
+	// minersPageSize is the page size used when paging through the miners of a pool.
+	minersPageSize = 100
+	// maxMinerPages bounds the number of pages fetched when paging through all miners of a pool.
+	maxMinerPages = 1000
+)
+
+// eachMinersPage calls fn with every page of miners of a pool until fn returns false or the last page was reached.
+// It fails with ErrTooManyPages if the last of maxMinerPages pages was full, since more miners may follow.
+func (c *Client) eachMinersPage(ctx context.Context, id string, fn func(miners []*MinerPerformanceStats) bool) error {
+	pageSize := c.clampPageSize(minersPageSize)
+	for page := 0; page < maxMinerPages; page++ {
+		miners, _, err := c.GetMiners(ctx, id, map[string]string{
+			"page":     strconv.Itoa(page),
+			"pageSize": strconv.Itoa(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+		if !fn(miners) || len(miners) < pageSize {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: pool %s has more than %d pages of miners", ErrTooManyPages, id, maxMinerPages)
+}
+
+// TopMiners returns the n miners of a pool with the highest hashrate, sorted by hashrate descending.
+// If the pool has fewer than n miners, all of them are returned.
+// miningcore returns miners sorted by hashrate, so only the first page is fetched if it holds n miners.
+func (c *Client) TopMiners(ctx context.Context, id string, n int) ([]*MinerPerformanceStats, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	var all []*MinerPerformanceStats
+	err := c.eachMinersPage(ctx, id, func(miners []*MinerPerformanceStats) bool {
+		all = append(all, miners...)
+		return len(all) < n
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Hashrate > all[j].Hashrate
+	})
+	if len(all) > n {
+		all = all[:n]
+	}
+	return all, nil
+}
+
+// TotalReportedHashrate sums the hashrate of all miners of a pool by paging through the miners endpoint,
+// to reconcile it against the hashrate reported by the pool itself, which is returned as poolReported.
+// Pages are fetched one after another and at most maxMinerPages pages are read,
+// ErrTooManyPages is returned for pools with more miners rather than a partial sum.
+//
+// On large pools this needs many requests. For most uses the pool hashrate from GetPool is sufficient.
+func (c *Client) TotalReportedHashrate(ctx context.Context, id string) (total, poolReported float64, err error) {
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	pool, _, err := c.GetPool(ctx, id)
+	if err != nil {
+		return 0, 0, err
+	}
+	if pool.PoolStats != nil {
+		poolReported = float64(pool.PoolStats.PoolHashrate)
+	}
+	err = c.eachMinersPage(ctx, id, func(miners []*MinerPerformanceStats) bool {
+		for _, m := range miners {
+			total += m.Hashrate
+		}
+		return true
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, poolReported, nil
+}
+
+// GetMinerAcrossPools fetches a miner from each of the given pools concurrently and returns the miner stats
+// by pool ID together with the pending balance summed up per coin symbol, since amounts of different coins
+// can't be added. Pools the address has no stats on map to nil without failing the batch.
+// Any other error is returned and cancels the outstanding requests. A 404 of a pool that is not in the pool list
+// counts as an unknown pool rather than an unknown miner and is returned as well.
+func (c *Client) GetMinerAcrossPools(ctx context.Context, ids []string, addr string) (map[string]*MinerStats, map[string]Amount, error) {
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	var (
+		miners = make([]*MinerStats, len(ids))
+		errs   = make([]error, len(ids))
+		pools  []*PoolInfo
+		fatal  = firstError{cancel: cancel}
+		wg     sync.WaitGroup
+	)
+	wg.Add(len(ids) + 1)
+	go func() {
+		defer wg.Done()
+		var err error
+		if pools, _, err = c.GetPools(ctx); err != nil {
+			fatal.set(err)
+		}
+	}()
+	for i, id := range ids {
+		go func(i int, id string) {
+			defer wg.Done()
+			miners[i], _, errs[i] = c.GetMiner(ctx, id, addr)
+			// unknown miners are only decided once the pool list is known
+			if errs[i] != nil && !IsMinerNotFound(errs[i]) {
+				fatal.set(errs[i])
+			}
+		}(i, id)
+	}
+	wg.Wait()
+	if fatal.err != nil {
+		return nil, nil, fatal.err
+	}
+
+	known := make(map[string]*PoolInfo, len(pools))
+	for _, p := range pools {
+		known[p.ID] = p
+	}
+	byPool := make(map[string]*MinerStats, len(ids))
+	pending := make(map[string]Amount)
+	for i, id := range ids {
+		switch {
+		case IsMinerNotFound(errs[i]) && known[id] != nil:
+			byPool[id] = nil
+			continue
+		case errs[i] != nil:
+			return nil, nil, errs[i]
+		}
+		byPool[id] = miners[i]
+		var coin string
+		if p := known[id]; p != nil && p.Coin != nil {
+			coin = p.Coin.Symbol
+		}
+		pending[coin] += Amount(miners[i].PendingBalance)
+	}
+	return byPool, pending, nil
+}
+
+// compositeContext derives the context shared by the requests of a composite helper, limited to the
+// composite timeout if one is set. The context must be canceled once the helper returns.
+func (c *Client) compositeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.compositeTimeout > 0 {
+		return context.WithTimeout(ctx, c.compositeTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// firstError records the first error of concurrent requests and cancels the others,
+// which then fail with errors of their own that are of no interest.
+// It must only be read after all requests returned.
+type firstError struct {
+	once   sync.Once
+	err    error
+	cancel context.CancelFunc
+}
+
+func (f *firstError) set(err error) {
+	f.once.Do(func() {
+		f.err = err
+		f.cancel()
+	})
+}
@@ -0,0 +1,439 @@
+package miningcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ErrNoBlocks is returned by helpers that need at least one block to compute a value.
+var ErrNoBlocks = errors.New("miningcore: no blocks found")
+
+// AverageEffort returns the average effort of the given blocks.
+// miningcore reports effort as a fraction, so 1 means 100%.
+func AverageEffort(blocks []*Block) float64 {
+	if len(blocks) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, b := range blocks {
+		sum += b.Effort
+	}
+	return sum / float64(len(blocks))
+}
+
+// AverageHashrate returns the time-weighted average pool hashrate of the performance samples over the window
+// that ends at now. Each sample counts from its creation until the next sample, the latest one until now,
+// so unevenly spaced samples are weighted by the time they cover. Parts of the window before the first sample
+// are left out of the average. Samples with an invalid creation time or after now are ignored.
+// If no sample was created within the window, 0 is returned.
+func AverageHashrate(samples []*PoolPerformance, window time.Duration, now time.Time) float64 {
+	points := make([]hashratePoint, 0, len(samples))
+	for _, s := range samples {
+		if s == nil {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, s.Created); err == nil {
+			points = append(points, hashratePoint{t, s.PoolHashrate})
+		}
+	}
+	return timeWeightedAverage(points, window, now)
+}
+
+// AverageMinerHashrate returns the time-weighted average hashrate of a miner, summed over its workers,
+// from the performance samples of MinerStats or GetMinerPerformance. See AverageHashrate for the weighting.
+func AverageMinerHashrate(samples []*WorkerStats, window time.Duration, now time.Time) float64 {
+	points := make([]hashratePoint, 0, len(samples))
+	for _, s := range samples {
+		if s == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s.Created)
+		if err != nil {
+			continue
+		}
+		var hashrate float64
+		for _, w := range s.Workers {
+			if w != nil {
+				hashrate += w.Hashrate
+			}
+		}
+		points = append(points, hashratePoint{t, hashrate})
+	}
+	return timeWeightedAverage(points, window, now)
+}
+
+type hashratePoint struct {
+	t        time.Time
+	hashrate float64
+}
+
+// timeWeightedAverage averages the hashrate of the points over the window ending at now,
+// holding every point until the next one.
+func timeWeightedAverage(points []hashratePoint, window time.Duration, now time.Time) float64 {
+	start := now.Add(-window)
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].t.Before(points[j].t)
+	})
+	// drop samples from the future
+	for len(points) > 0 && points[len(points)-1].t.After(now) {
+		points = points[:len(points)-1]
+	}
+	if window <= 0 || len(points) == 0 || points[len(points)-1].t.Before(start) {
+		return 0
+	}
+
+	var (
+		sum     float64
+		covered time.Duration
+	)
+	for i, p := range points {
+		from, to := p.t, now
+		if i+1 < len(points) {
+			to = points[i+1].t
+		}
+		if from.Before(start) {
+			from = start
+		}
+		if d := to.Sub(from); d > 0 {
+			sum += p.hashrate * d.Seconds()
+			covered += d
+		}
+	}
+	if covered == 0 {
+		// the only sample in the window was created at now
+		return points[len(points)-1].hashrate
+	}
+	return sum / covered.Seconds()
+}
+
+// PoolLuck returns the average effort of the last n confirmed blocks of a pool as a percentage.
+// The blocks are fetched in pages of at most the maximum page size until n blocks were collected.
+// If the pool has found fewer than n confirmed blocks, the luck is computed over the available ones.
+// ErrNoBlocks is returned if the pool has no confirmed blocks at all.
+func (c *Client) PoolLuck(ctx context.Context, id string, lastN int) (float64, error) {
+	if lastN <= 0 {
+		return 0, errors.New("miningcore: lastN must be greater than zero")
+	}
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	pageSize := c.clampPageSize(lastN)
+	var blocks []*Block
+	for page := 0; len(blocks) < lastN; page++ {
+		res, _, err := c.GetPoolBlocks(ctx, id, map[string]string{
+			"page":     strconv.Itoa(page),
+			"pageSize": strconv.Itoa(pageSize),
+			"state":    "Confirmed",
+		})
+		if err != nil {
+			return 0, err
+		}
+		blocks = append(blocks, res.Result...)
+		if len(res.Result) < pageSize || (res.Meta != nil && res.PageCount > 0 && int64(page+1) >= res.PageCount) {
+			break
+		}
+	}
+	if len(blocks) > lastN {
+		blocks = blocks[:lastN]
+	}
+	if len(blocks) == 0 {
+		return 0, ErrNoBlocks
+	}
+	return AverageEffort(blocks) * 100, nil
+}
+
+// NetConfirmedReward returns the sum of the rewards of all confirmed blocks.
+// Pending, orphaned and blocks with an unknown status are ignored.
+func NetConfirmedReward(blocks []*Block) Amount {
+	var sum Amount
+	for _, b := range blocks {
+		if normalizeBlockStatus(b.Status) == BlockStatusConfirmed {
+			sum += Amount(b.Reward)
+		}
+	}
+	return sum
+}
+
+// TotalBlockRewards pages through all blocks found by a pool and returns the sum of their rewards and
+// the number of blocks summed up. If onlyConfirmed is set, only confirmed blocks are counted, otherwise
+// all blocks including pending and orphaned ones. A pool without blocks returns 0 and 0.
+// Paging stops at the last page reported by the server. Pools with more than maxBlockPages pages of blocks
+// fail with ErrTooManyPages rather than returning a partial total.
+//
+// Every page is a request subject to the retry and rate limit settings of the client, so on pools with
+// a long history this is slow and costly. Cache the result rather than calling it on every page view.
+func (c *Client) TotalBlockRewards(ctx context.Context, id string, onlyConfirmed bool) (Amount, int, error) {
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	pageSize := c.clampPageSize(blocksPageSize)
+	var (
+		total Amount
+		count int
+	)
+	for page := 0; page < maxBlockPages; page++ {
+		params := map[string]string{
+			"page":     strconv.Itoa(page),
+			"pageSize": strconv.Itoa(pageSize),
+		}
+		if onlyConfirmed {
+			params["state"] = "Confirmed"
+		}
+		res, _, err := c.GetPoolBlocks(ctx, id, params)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, b := range res.Result {
+			// servers that don't filter by state return all blocks
+			if b == nil || (onlyConfirmed && normalizeBlockStatus(b.Status) != BlockStatusConfirmed) {
+				continue
+			}
+			total += Amount(b.Reward)
+			count++
+		}
+		if len(res.Result) < pageSize || (res.Meta != nil && res.PageCount > 0 && int64(page+1) >= res.PageCount) {
+			return total, count, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("%w: pool %s has more than %d pages of blocks", ErrTooManyPages, id, maxBlockPages)
+}
+
+// OrphanRate returns the fraction of orphaned blocks out of all settled (confirmed or orphaned) blocks.
+// Pending blocks and blocks with an unknown status are not counted.
+func OrphanRate(blocks []*Block) float64 {
+	var confirmed, orphaned int
+	for _, b := range blocks {
+		switch normalizeBlockStatus(b.Status) {
+		case BlockStatusConfirmed:
+			confirmed++
+		case BlockStatusOrphaned:
+			orphaned++
+		}
+	}
+	if confirmed+orphaned == 0 {
+		return 0
+	}
+	return float64(orphaned) / float64(confirmed+orphaned)
+}
+
+// ServerTimeSkew returns the difference between the server clock and the local clock,
+// derived from the Date header of a request to the pools endpoint.
+// A positive value means the server clock is ahead. The Date header only has a
+// resolution of one second, so skews below that are not meaningful.
+func (c *Client) ServerTimeSkew(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	resp, err := c.do(ctx, "/api/pools", http.MethodGet, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	end := time.Now()
+
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return 0, errors.New("miningcore: response has no Date header")
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return 0, fmt.Errorf("miningcore: invalid Date header %q: %w", date, err)
+	}
+	// compare against the middle of the round trip
+	local := start.Add(end.Sub(start) / 2)
+	return serverTime.Sub(local), nil
+}
+
+// earningsPageSize is the page size used when paging through daily earnings.
+const earningsPageSize = 100
+
+// GetMinerEarningsSeries returns the daily earnings of a miner between from and to (both inclusive, by calendar day
+// in the time zone of the client, see WithTimeZone), sorted from oldest to newest. Earnings that fall on the
+// same local day are summed up and dated at the start of that day. It pages through the daily earnings endpoint as needed.
+// If fill is true, days without earnings are added as zero-amount entries so the series has one entry per day.
+func (c *Client) GetMinerEarningsSeries(ctx context.Context, id, addr string, from, to time.Time, fill bool) ([]*DailyEarning, error) {
+	from = c.truncateDay(from)
+	to = c.truncateDay(to)
+	if to.Before(from) {
+		return nil, errors.New("miningcore: to must not be before from")
+	}
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+
+	byDay := make(map[time.Time]*DailyEarning)
+	pageSize := c.clampPageSize(earningsPageSize)
+	for page := 0; ; page++ {
+		res, _, err := c.GetMinerDailyEarnings(ctx, id, addr, map[string]string{
+			"page":     strconv.Itoa(page),
+			"pageSize": strconv.Itoa(pageSize),
+		})
+		if err != nil {
+			return nil, err
+		}
+		reachedFrom := false
+		for _, e := range res.Result {
+			t, err := c.ParseTime(e.Date)
+			if err != nil {
+				return nil, fmt.Errorf("miningcore: invalid earnings date %q: %w", e.Date, err)
+			}
+			day := c.truncateDay(t)
+			if day.Before(from) {
+				reachedFrom = true
+				continue
+			}
+			if day.After(to) {
+				continue
+			}
+			if prev, ok := byDay[day]; ok {
+				prev.Amount += e.Amount
+				continue
+			}
+			byDay[day] = &DailyEarning{Amount: e.Amount, Date: day.Format(time.RFC3339)}
+		}
+		if reachedFrom || len(res.Result) < pageSize || res.Meta == nil || int64(page+1) >= res.PageCount {
+			break
+		}
+	}
+
+	series := make([]*DailyEarning, 0, len(byDay))
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		e, ok := byDay[day]
+		switch {
+		case ok:
+			series = append(series, e)
+		case fill:
+			series = append(series, &DailyEarning{Date: day.Format(time.RFC3339)})
+		}
+	}
+	return series, nil
+}
+
+// truncateDay returns the start of the day of t in the time zone of the client.
+func (c *Client) truncateDay(t time.Time) time.Time {
+	t = t.In(c.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.location)
+}
+
+// DiffBlocks compares two snapshots of the blocks of a pool, for example from consecutive polls.
+// It returns the blocks of curr that are not in prev, and the blocks that were not orphaned in prev
+// but are orphaned in curr. Blocks are matched by height and hash, a block without hash (not yet known
+// while pending) matches any block at the same height. The order of the slices doesn't matter and
+// blocks that dropped out of curr, e.g. because they moved to an older page, are ignored.
+func DiffBlocks(prev, curr []*Block) (added []*Block, orphaned []*Block) {
+	byHeight := make(map[int64][]*Block, len(prev))
+	for _, b := range prev {
+		byHeight[b.BlockHeight] = append(byHeight[b.BlockHeight], b)
+	}
+	for _, b := range curr {
+		old := matchBlock(byHeight[b.BlockHeight], b)
+		switch {
+		case old == nil:
+			added = append(added, b)
+		case normalizeBlockStatus(b.Status) == BlockStatusOrphaned && normalizeBlockStatus(old.Status) != BlockStatusOrphaned:
+			orphaned = append(orphaned, b)
+		}
+	}
+	return added, orphaned
+}
+
+// matchBlock returns the block of candidates that is the same block as b.
+func matchBlock(candidates []*Block, b *Block) *Block {
+	for _, c := range candidates {
+		if c.Hash == b.Hash || c.Hash == "" || b.Hash == "" {
+			return c
+		}
+	}
+	return nil
+}
+
+// ErrUnknownBlockTime is returned by EstimateMinerDailyEarnings if the pool doesn't report the block time of its coin.
+var ErrUnknownBlockTime = errors.New("miningcore: block time of coin unknown")
+
+// EstimateDailyEarnings returns the expected earnings of a miner per day using the standard PoW formula:
+// the share of the network hashrate of the miner times the coins mined by the network per day, minus the pool fee.
+// poolFee is a percentage, like PoolInfo.PoolFeePercent.
+//
+// The estimate assumes constant hashrates, difficulty and block reward, and 100% luck of the pool.
+// Transaction fees, uncle rewards and orphaned blocks are not accounted for.
+// If the network hashrate is not positive, 0 is returned.
+func EstimateDailyEarnings(minerHashrate, networkHashrate float64, blockReward Amount, blocksPerDay float64, poolFee float64) Amount {
+	if networkHashrate <= 0 || minerHashrate <= 0 {
+		return 0
+	}
+	share := minerHashrate / networkHashrate
+	if share > 1 {
+		share = 1
+	}
+	return Amount(share * blocksPerDay * float64(blockReward) * (1 - poolFee/100))
+}
+
+// EstimateMinerDailyEarnings estimates the daily earnings of a miner with EstimateDailyEarnings at its current hashrate.
+// The block reward is taken from the latest confirmed block of the pool, the number of blocks per day
+// from the block time of the coin and the fee from EffectivePoolFee. ErrNoBlocks is returned if the pool has no confirmed blocks and
+// ErrUnknownBlockTime if the pool doesn't report the block time.
+func (c *Client) EstimateMinerDailyEarnings(ctx context.Context, id, addr string) (Amount, error) {
+	ctx, cancel := c.compositeContext(ctx)
+	defer cancel()
+	pool, _, err := c.GetPool(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if pool.Coin == nil || pool.Coin.BlockTime <= 0 {
+		return 0, ErrUnknownBlockTime
+	}
+	if pool.NetworkStats == nil || pool.NetworkStats.NetworkHashrate <= 0 {
+		return 0, nil
+	}
+	miner, _, err := c.GetMiner(ctx, id, addr)
+	if err != nil {
+		return 0, err
+	}
+	blocks, _, err := c.GetPoolBlocks(ctx, id, map[string]string{
+		"page":     "0",
+		"pageSize": "1",
+		"state":    "Confirmed",
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(blocks.Result) == 0 {
+		return 0, ErrNoBlocks
+	}
+	blocksPerDay := (24 * time.Hour).Seconds() / pool.Coin.BlockTime
+	return EstimateDailyEarnings(miner.Hashrate(), pool.NetworkStats.NetworkHashrate, Amount(blocks.Result[0].Reward), blocksPerDay, pool.effectiveFeePercent()), nil
+}
+
+// EffectivePoolFee returns the percentage of the block rewards a pool deducts before paying its miners.
+// It is the sum of the pool fee and the dev and donation fees, if the pool reports them, capped at 100.
+// Transaction fees of payouts, which some coins deduct from the paid amount, are not included.
+func (c *Client) EffectivePoolFee(ctx context.Context, id string) (float64, error) {
+	pool, _, err := c.GetPool(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return pool.effectiveFeePercent(), nil
+}
+
+// GetBanningConfig returns the banning policy of a pool, see PoolInfo.BanningConfig.
+// Pools without banning, or that don't report it, return a zero config with Enabled false.
+func (c *Client) GetBanningConfig(ctx context.Context, id string) (*BanningConfig, error) {
+	pool, _, err := c.GetPool(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return pool.BanningConfig(), nil
+}
+
+// effectiveFeePercent returns the total fee of the pool in percent, ignoring negative fees.
+func (p *PoolInfo) effectiveFeePercent() float64 {
+	var total float64
+	for _, fee := range []float64{p.PoolFeePercent, p.DevFeePercent, p.DonationPercent} {
+		if fee > 0 {
+			total += fee
+		}
+	}
+	if total > 100 {
+		return 100
+	}
+	return total
+}
@@ -3,10 +3,18 @@ package miningcore
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -30,11 +38,80 @@ func TestBuildRequestUrl(t *testing.T) {
 
 }
 
+func TestBuildRequestURLCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		endpoint string
+		params   []map[string]string
+		want     string
+	}{
+		{"no params", "http://localhost:8080", "/api/pools", nil, "http://localhost:8080/api/pools"},
+		{"empty params", "http://localhost:8080", "/api/pools", []map[string]string{{}}, "http://localhost:8080/api/pools"},
+		{"nil params", "http://localhost:8080", "/api/pools", []map[string]string{nil}, "http://localhost:8080/api/pools"},
+		{"trailing slash", "http://localhost:8080/", "/api/pools", nil, "http://localhost:8080/api/pools"},
+		{"no leading slash", "http://localhost:8080", "api/pools", nil, "http://localhost:8080/api/pools"},
+		{"both slashes", "http://localhost:8080/", "api/pools", nil, "http://localhost:8080/api/pools"},
+		{"https", "https://pool.example.com", "/api/pools", nil, "https://pool.example.com/api/pools"},
+		{
+			"multiple param maps",
+			"http://localhost:8080", "/api/pools",
+			[]map[string]string{{"page": "0", "pageSize": "10"}, {"pageSize": "20", "state": "Confirmed"}},
+			"http://localhost:8080/api/pools?page=0&pageSize=20&state=Confirmed",
+		},
+		{
+			"special characters",
+			"http://localhost:8080", "/api/pools",
+			[]map[string]string{{"q": "a b&c=d/e?f#g", "ü": "%"}},
+			"http://localhost:8080/api/pools?q=a+b%26c%3Dd%2Fe%3Ff%23g&%C3%BC=%25",
+		},
+		{"base path", "http://localhost:8080/miningcore", "/api/pools", nil, "http://localhost:8080/miningcore/api/pools"},
+		{"base path with trailing slash", "http://localhost:8080/miningcore/", "/api/pools", nil, "http://localhost:8080/miningcore/api/pools"},
+		{
+			"base query",
+			"http://localhost:8080/miningcore?tenant=a&key=1", "/api/pools",
+			[]map[string]string{{"tenant": "b", "page": "1"}},
+			"http://localhost:8080/miningcore/api/pools?key=1&page=1&tenant=b",
+		},
+		{"escaped endpoint", "http://localhost:8080", "/api/pools/a%2Fb/blocks", nil, "http://localhost:8080/api/pools/a%2Fb/blocks"},
+		{"escaped base path", "http://localhost:8080/mining%20core", "/api/pools", nil, "http://localhost:8080/mining%20core/api/pools"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := buildRequestURL(tt.base, tt.endpoint, tt.params...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, url)
+		})
+	}
+
+	_, err := buildRequestURL("http://local host", "/api/pools")
+	assert.Error(t, err)
+	_, err = buildRequestURL("http://localhost:8080", "/api/pools/%zz")
+	assert.Error(t, err)
+}
+
+func TestBasePath(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`{"pools": []}`))
+	}))
+	defer srv.Close()
+
+	_, _, err := New(srv.URL + "/miningcore/").GetPools(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "/miningcore/api/pools", path)
+}
+
 func TestMain(m *testing.M) {
 	handler := http.NewServeMux()
 	handler.HandleFunc("/api/pools", poolsReq)
 	handler.HandleFunc("/api/pools/eth", poolReq)
 	handler.HandleFunc("/api/pools/mock", poolMock)
+	handler.HandleFunc("/api/v2/pools/eth/blocks", blocksReq)
+	handler.HandleFunc("/api/v2/pools/eth/miners/0x000000000000000000000000000000000000dEaD/earnings/daily", fileReq("testdata/earnings_daily.json"))
+	handler.HandleFunc("/api/pools/eth/miners/0x000000000000000000000000000000000000dEaD", fileReq("testdata/miner_eth.json"))
+	handler.HandleFunc("/api/v2/pools/eth/miners/0x000000000000000000000000000000000000dEaD/payments", fileReq("testdata/payments_miner.json"))
 
 	testServer = httptest.NewServer(handler)
 	defer testServer.Close()
@@ -65,6 +142,46 @@ func poolReq(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// blocksReq serves the blocks fixture and honors the state and pageSize parameters.
+func blocksReq(w http.ResponseWriter, r *http.Request) {
+	data, err := os.ReadFile("testdata/blocks_eth.json")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var res BlocksRes
+	if err := json.Unmarshal(data, &res); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	q := r.URL.Query()
+	if state := q.Get("state"); state != "" {
+		blocks := make([]*Block, 0, len(res.Result))
+		for _, b := range res.Result {
+			if strings.EqualFold(string(b.Status), state) {
+				blocks = append(blocks, b)
+			}
+		}
+		res.Result = blocks
+	}
+	if n, err := strconv.Atoi(q.Get("pageSize")); err == nil && n < len(res.Result) {
+		res.Result = res.Result[:n]
+	}
+	json.NewEncoder(w).Encode(res) // nolint:errcheck
+}
+
+// fileReq returns a handler serving the given file.
+func fileReq(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(data)
+	}
+}
+
 func poolMock(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusForbidden)
 }
@@ -99,3 +216,1092 @@ func TestPoolMock(t *testing.T) {
 	assert.Equal(t, http.StatusForbidden, code)
 	assert.Nil(t, pool)
 }
+
+func TestAverageEffort(t *testing.T) {
+	assert.Equal(t, 0.0, AverageEffort(nil))
+	assert.InDelta(t, 0.75, AverageEffort([]*Block{{Effort: 0.5}, {Effort: 1}}), 1e-9)
+}
+
+func TestAverageHashrate(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(hour, min int) string {
+		return time.Date(2023, 5, 1, hour, min, 0, 0, time.UTC).Format(time.RFC3339)
+	}
+	// unsorted and unevenly spaced
+	samples := []*PoolPerformance{
+		{Created: at(11, 30), PoolHashrate: 400},
+		{Created: at(9, 0), PoolHashrate: 100},
+		{Created: at(11, 0), PoolHashrate: 200},
+		{Created: "invalid", PoolHashrate: 1e9},
+		{Created: at(13, 0), PoolHashrate: 1e9},
+		nil,
+	}
+	tests := []struct {
+		window time.Duration
+		want   float64
+	}{
+		{time.Hour, 300},
+		{2 * time.Hour, 200},
+		// the window starts before the first sample
+		{6 * time.Hour, 500.0 / 3},
+		{45 * time.Minute, 1000.0 / 3},
+		// the latest sample is older than the window
+		{20 * time.Minute, 0},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, AverageHashrate(samples, tt.window, now), 1e-9, tt.window.String())
+	}
+
+	// no sample within the window
+	assert.Equal(t, 0.0, AverageHashrate(samples[1:2], time.Hour, now))
+	assert.Equal(t, 0.0, AverageHashrate(nil, time.Hour, now))
+	assert.Equal(t, 50.0, AverageHashrate([]*PoolPerformance{{Created: at(12, 0), PoolHashrate: 50}}, time.Hour, now))
+
+	miner := []*WorkerStats{
+		{Created: at(10, 0), Workers: map[string]*WorkerPerformanceStats{"rig1": {Hashrate: 10}, "rig2": {Hashrate: 20}}},
+		{Created: at(11, 15), Workers: map[string]*WorkerPerformanceStats{"rig1": {Hashrate: 60}, "rig2": nil}},
+		{Created: at(11, 45), Workers: nil},
+	}
+	// 30 for 15m, 60 for 30m and 0 for 15m
+	assert.InDelta(t, 37.5, AverageMinerHashrate(miner, time.Hour, now), 1e-9)
+	assert.InDelta(t, 30, AverageMinerHashrate(miner, time.Hour, now.Add(-45*time.Minute)), 1e-9)
+	assert.Equal(t, 0.0, AverageMinerHashrate(nil, time.Hour, now))
+}
+
+func TestPoolLuck(t *testing.T) {
+	luck, err := newClient().PoolLuck(context.Background(), "eth", 2)
+	assert.NoError(t, err)
+	assert.InDelta(t, 100, luck, 1e-9)
+
+	luck, err = newClient().PoolLuck(context.Background(), "eth", 1)
+	assert.NoError(t, err)
+	assert.InDelta(t, 120, luck, 1e-9)
+
+	// fewer confirmed blocks than requested are averaged as available
+	luck, err = newClient().PoolLuck(context.Background(), "eth", 10)
+	assert.NoError(t, err)
+	assert.InDelta(t, 100, luck, 1e-9)
+
+	_, err = newClient().PoolLuck(context.Background(), "eth", 0)
+	assert.Error(t, err)
+}
+
+func TestNetConfirmedReward(t *testing.T) {
+	blocks := []*Block{
+		{Status: "confirmed", Reward: 2},
+		{Status: "Confirmed", Reward: 1.5},
+		{Status: "orphaned", Reward: 2},
+		{Status: "pending", Reward: 2},
+		{Status: "something", Reward: 2},
+	}
+	assert.InDelta(t, 3.5, float64(NetConfirmedReward(blocks)), 1e-9)
+	assert.Equal(t, Amount(0), NetConfirmedReward(nil))
+}
+
+func TestTotalBlockRewards(t *testing.T) {
+	const total = 250
+	var (
+		requests    int32
+		filterState bool
+	)
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v2/pools/eth/blocks", func(w http.ResponseWriter, r *http.Request) {
+		// the first request fails and is retried
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var blocks []*Block
+		for i := 0; i < total; i++ {
+			b := &Block{BlockHeight: int64(i), Status: BlockStatusConfirmed, Reward: 2}
+			if i%5 == 0 {
+				b.Status = BlockStatusOrphaned
+			}
+			if !filterState || r.URL.Query().Get("state") != "Confirmed" || b.Status == BlockStatusConfirmed {
+				blocks = append(blocks, b)
+			}
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		res := BlocksRes{Meta: &Meta{PageCount: int64((len(blocks) + pageSize - 1) / pageSize), Success: true}, Result: []*Block{}}
+		for i := page * pageSize; i < len(blocks) && i < (page+1)*pageSize; i++ {
+			res.Result = append(res.Result, blocks[i])
+		}
+		json.NewEncoder(w).Encode(res) // nolint:errcheck
+	})
+	handler.HandleFunc("/api/v2/pools/empty/blocks", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pageCount": 0, "result": []}`))
+	})
+	var ignoredPages int32
+	handler.HandleFunc("/api/v2/pools/ignored/blocks", func(w http.ResponseWriter, r *http.Request) {
+		// the same full page for every page and no page count
+		atomic.AddInt32(&ignoredPages, 1)
+		w.Write([]byte(`{"result": [{"reward": 1, "status": "confirmed"}]}`))
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	client := New(srv.URL, WithRetry(2, time.Millisecond))
+	ctx := context.Background()
+
+	sum, count, err := client.TotalBlockRewards(ctx, "eth", false)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(2*total), sum)
+	assert.Equal(t, total, count)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+
+	// confirmed blocks are filtered client side if the server ignores the state
+	atomic.StoreInt32(&requests, 1)
+	sum, count, err = client.TotalBlockRewards(ctx, "eth", true)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(400), sum)
+	assert.Equal(t, 200, count)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+
+	atomic.StoreInt32(&requests, 1)
+	filterState = true
+	sum, count, err = client.TotalBlockRewards(ctx, "eth", true)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(400), sum)
+	assert.Equal(t, 200, count)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	sum, count, err = client.TotalBlockRewards(ctx, "empty", true)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(0), sum)
+	assert.Equal(t, 0, count)
+
+	// paging is bounded if the server ignores the page, and the partial total is not returned
+	_, _, err = New(srv.URL, WithMaxPageSize(1)).TotalBlockRewards(ctx, "ignored", false)
+	assert.ErrorIs(t, err, ErrTooManyPages)
+	assert.Equal(t, int32(maxBlockPages), atomic.LoadInt32(&ignoredPages))
+
+	_, _, err = client.TotalBlockRewards(ctx, "unknown", false)
+	assert.True(t, IsNotFound(err))
+}
+
+func TestOrphanRate(t *testing.T) {
+	blocks := []*Block{
+		{Status: "confirmed"},
+		{Status: " CONFIRMED "},
+		{Status: "confirmed"},
+		{Status: "Orphaned"},
+		{Status: "pending"},
+		{Status: ""},
+	}
+	assert.InDelta(t, 0.25, OrphanRate(blocks), 1e-9)
+	assert.Equal(t, 0.0, OrphanRate([]*Block{{Status: "pending"}}))
+}
+
+func TestServerTimeSkew(t *testing.T) {
+	skew, err := newClient().ServerTimeSkew(context.Background())
+	assert.NoError(t, err)
+	assert.InDelta(t, 0, float64(skew), float64(2*time.Second))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	}))
+	defer srv.Close()
+	skew, err = New(srv.URL).ServerTimeSkew(context.Background())
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(-time.Hour), float64(skew), float64(2*time.Second))
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Date"] = nil // suppress the automatic Date header
+	}))
+	defer srv.Close()
+	_, err = New(srv.URL).ServerTimeSkew(context.Background())
+	assert.Error(t, err)
+}
+
+func TestPoolBlocksCursor(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v2/pools/paged/blocks", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		json.NewEncoder(w).Encode(BlocksRes{ // nolint:errcheck
+			Meta:   &Meta{PageCount: 2, Success: true},
+			Result: []*Block{{BlockHeight: int64(100 - page)}},
+		})
+	})
+	handler.HandleFunc("/api/v2/pools/token/blocks", func(w http.ResponseWriter, r *http.Request) {
+		res := BlocksRes{Meta: &Meta{PageCount: 1, Success: true}}
+		switch r.URL.Query().Get("pageToken") {
+		case "":
+			res.NextPageToken = "abc"
+			res.Result = []*Block{{BlockHeight: 2}}
+		case "abc":
+			res.Result = []*Block{{BlockHeight: 1}}
+		}
+		json.NewEncoder(w).Encode(res) // nolint:errcheck
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	client := New(srv.URL)
+
+	// page number fallback
+	blocks, next, code, err := client.GetPoolBlocksCursor(context.Background(), "paged", "", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(100), blocks[0].BlockHeight)
+	assert.Equal(t, "page:1", next)
+	blocks, next, _, err = client.GetPoolBlocksCursor(context.Background(), "paged", next, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(99), blocks[0].BlockHeight)
+	assert.Empty(t, next)
+
+	// server provided cursor
+	blocks, next, _, err = client.GetPoolBlocksCursor(context.Background(), "token", "", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), blocks[0].BlockHeight)
+	assert.Equal(t, "abc", next)
+	blocks, next, _, err = client.GetPoolBlocksCursor(context.Background(), "token", next, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), blocks[0].BlockHeight)
+	assert.Empty(t, next)
+
+	_, _, _, err = client.GetPoolBlocksCursor(context.Background(), "paged", "page:x", 1)
+	assert.Error(t, err)
+}
+
+func TestPayoutScheme(t *testing.T) {
+	scheme, code, err := newClient().GetPayoutScheme(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "PPLNS", scheme.Scheme)
+	assert.Equal(t, 0.1, scheme.MinimumPayment)
+	assert.False(t, scheme.Enabled)
+}
+
+func TestPayoutSchemeConfig(t *testing.T) {
+	var cfg PayoutSchemeConfig
+	assert.NoError(t, json.Unmarshal([]byte(`{"factor": 2.5, "window": 10}`), &cfg))
+	assert.Equal(t, 2.5, cfg.Factor)
+	assert.JSONEq(t, "10", string(cfg.Extra["window"]))
+
+	cfg = PayoutSchemeConfig{}
+	assert.NoError(t, json.Unmarshal([]byte(`[[[]]]`), &cfg))
+	assert.Equal(t, 0.0, cfg.Factor)
+	assert.Nil(t, cfg.Extra)
+}
+
+const testMiner = "0x000000000000000000000000000000000000dEaD"
+
+func TestMinerEarningsSeries(t *testing.T) {
+	from := time.Date(2022, 6, 17, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 6, 20, 23, 0, 0, 0, time.UTC)
+
+	series, err := newClient().GetMinerEarningsSeries(context.Background(), "eth", testMiner, from, to, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(series))
+	assert.Equal(t, "2022-06-17T00:00:00Z", series[0].Date)
+	assert.Equal(t, "2022-06-20T00:00:00Z", series[2].Date)
+
+	series, err = newClient().GetMinerEarningsSeries(context.Background(), "eth", testMiner, from, to, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(series))
+	assert.Equal(t, 0.1, series[0].Amount)
+	assert.Equal(t, 0.2, series[1].Amount)
+	assert.Equal(t, "2022-06-19T00:00:00Z", series[2].Date)
+	assert.Equal(t, 0.0, series[2].Amount)
+	assert.Equal(t, 0.3, series[3].Amount)
+
+	_, err = newClient().GetMinerEarningsSeries(context.Background(), "eth", testMiner, to, from, true)
+	assert.Error(t, err)
+}
+
+func TestMinersPage(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/total/miners", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Total-Count", "1234")
+		w.Write([]byte(`[{"miner": "a", "hashrate": 10}, {"miner": "b", "hashrate": 5}]`))
+	})
+	handler.HandleFunc("/api/pools/nototal/miners", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	client := New(srv.URL)
+
+	page, code, err := client.GetMinersPage(context.Background(), "total")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(1234), page.Total)
+	assert.Equal(t, 2, len(page.Items))
+
+	page, _, err = client.GetMinersPage(context.Background(), "nototal")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), page.Total)
+	assert.Empty(t, page.Items)
+}
+
+func TestMinerDashboard(t *testing.T) {
+	d, err := newClient().GetMinerDashboard(context.Background(), "eth", testMiner)
+	assert.NoError(t, err)
+	assert.Empty(t, d.Errors)
+	assert.Equal(t, 0.42, d.Miner.PendingBalance)
+	assert.Equal(t, 2, len(d.Payments.Result))
+	assert.Equal(t, 4, len(d.Earnings.Result))
+
+	// unknown miner, all parts fail
+	d, err = newClient().GetMinerDashboard(context.Background(), "eth", "unknown")
+	assert.Error(t, err)
+	assert.Equal(t, 3, len(d.Errors))
+}
+
+func TestMinerDashboardPartial(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/eth/miners/"+testMiner, fileReq("testdata/miner_eth.json"))
+	handler.HandleFunc("/api/v2/pools/eth/miners/"+testMiner+"/payments", fileReq("testdata/payments_miner.json"))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	d, err := New(srv.URL).GetMinerDashboard(context.Background(), "eth", testMiner)
+	assert.NoError(t, err)
+	assert.NotNil(t, d.Miner)
+	assert.NotNil(t, d.Payments)
+	assert.Nil(t, d.Earnings)
+	assert.Equal(t, 1, len(d.Errors))
+	assert.Error(t, d.Errors[DashboardEarnings])
+}
+
+func TestWaitForBlockConfirmation(t *testing.T) {
+	var polls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		res := BlocksRes{Meta: &Meta{PageCount: 1, Success: true}}
+		switch {
+		case r.URL.Path == "/api/v2/pools/orphan/blocks":
+			res.Result = []*Block{{BlockHeight: 10, Status: "orphaned"}}
+		case polls == 1:
+			res.Result = []*Block{{BlockHeight: 11, Status: "pending"}}
+		case polls == 2:
+			res.Result = []*Block{{BlockHeight: 11, Status: "pending"}, {BlockHeight: 10, Status: "pending", ConfirmationProgress: 0.5}}
+		default:
+			res.Result = []*Block{{BlockHeight: 11, Status: "pending"}, {BlockHeight: 10, Status: "confirmed", ConfirmationProgress: 1}}
+		}
+		json.NewEncoder(w).Encode(res) // nolint:errcheck
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+
+	b, err := client.WaitForBlockConfirmation(context.Background(), "eth", 10, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), b.BlockHeight)
+	assert.Equal(t, 3, polls)
+
+	b, err = client.WaitForBlockConfirmation(context.Background(), "orphan", 10, time.Millisecond)
+	assert.ErrorIs(t, err, ErrBlockOrphaned)
+	assert.NotNil(t, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.WaitForBlockConfirmation(ctx, "eth", 12, time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAdminPools(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/admin/pools", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer admin" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"pools": [{"id": "eth", "totalPendingBalance": 12.5, "processingStatus": "idle", "poolStats": {"connectedMiners": 3}}]}`))
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	pools, code, err := New(srv.URL, WithAuthToken("admin")).GetAdminPools(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, len(pools))
+	assert.Equal(t, "eth", pools[0].ID)
+	assert.Equal(t, 12.5, pools[0].TotalPendingBalance)
+	assert.Equal(t, "idle", pools[0].ProcessingStatus)
+	assert.Equal(t, int32(3), pools[0].PoolStats.ConnectedMiners)
+
+	_, code, err = New(srv.URL).GetAdminPools(context.Background())
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.True(t, IsUnauthorized(err))
+}
+
+func TestMinerWorker(t *testing.T) {
+	miner, _, err := newClient().GetMiner(context.Background(), "eth", testMiner)
+	assert.NoError(t, err)
+
+	w, ok := miner.Worker("rig1")
+	assert.True(t, ok)
+	assert.Equal(t, 150000000.0, w.Hashrate)
+	assert.Equal(t, 1.5, w.SharesPerSecond)
+
+	w, ok = miner.Worker(DefaultWorker)
+	assert.True(t, ok)
+	assert.Equal(t, 50000000.0, w.Hashrate)
+
+	_, ok = miner.Worker("rig2")
+	assert.False(t, ok)
+
+	_, ok = (&MinerStats{}).Worker("rig1")
+	assert.False(t, ok)
+}
+
+func TestMinerHashrate(t *testing.T) {
+	miner, _, err := newClient().GetMiner(context.Background(), "eth", testMiner)
+	assert.NoError(t, err)
+	assert.Equal(t, 200000000.0, miner.Hashrate())
+	assert.Equal(t, 0.0, (&MinerStats{}).Hashrate())
+}
+
+func TestMinerPoolShare(t *testing.T) {
+	share, err := newClient().MinerPoolShare(context.Background(), "eth", testMiner)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.01, share, 1e-9)
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/idle", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pool": {"id": "idle", "poolStats": {"poolHashrate": 0}}}`))
+	})
+	handler.HandleFunc("/api/pools/idle/miners/"+testMiner, fileReq("testdata/miner_eth.json"))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	share, err = New(srv.URL).MinerPoolShare(context.Background(), "idle", testMiner)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, share)
+
+	_, err = New(srv.URL).MinerPoolShare(context.Background(), "missing", testMiner)
+	assert.Error(t, err)
+}
+
+// minersHandler serves total miners sorted by hashrate descending, honoring page and pageSize.
+func minersHandler(total int, requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		miners := []*MinerPerformanceStats{}
+		for i := page * pageSize; i < total && i < (page+1)*pageSize; i++ {
+			miners = append(miners, &MinerPerformanceStats{Miner: strconv.Itoa(i), Hashrate: float64(total - i)})
+		}
+		json.NewEncoder(w).Encode(miners) // nolint:errcheck
+	}
+}
+
+func TestTopMiners(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(minersHandler(250, &requests))
+	defer srv.Close()
+	client := New(srv.URL)
+
+	miners, err := client.TopMiners(context.Background(), "eth", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, len(miners))
+	assert.Equal(t, 250.0, miners[0].Hashrate)
+	assert.Equal(t, 241.0, miners[9].Hashrate)
+	assert.Equal(t, 1, requests)
+
+	requests = 0
+	miners, err = client.TopMiners(context.Background(), "eth", 150)
+	assert.NoError(t, err)
+	assert.Equal(t, 150, len(miners))
+	assert.Equal(t, 101.0, miners[149].Hashrate)
+	assert.Equal(t, 2, requests)
+
+	requests = 0
+	miners, err = client.TopMiners(context.Background(), "eth", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 250, len(miners))
+	assert.Equal(t, 3, requests)
+
+	requests = 0
+	miners, err = New(srv.URL, WithMaxPageSize(50)).TopMiners(context.Background(), "eth", 120)
+	assert.NoError(t, err)
+	assert.Equal(t, 120, len(miners))
+	assert.Equal(t, 3, requests)
+}
+
+func TestDiffBlocks(t *testing.T) {
+	prev := []*Block{
+		{BlockHeight: 3, Hash: "0x03", Status: "pending"},
+		{BlockHeight: 2, Hash: "0x02", Status: "pending"},
+		{BlockHeight: 1, Hash: "0x01", Status: "orphaned"},
+		{BlockHeight: 0, Hash: "0x00", Status: "confirmed"},
+	}
+	curr := []*Block{
+		{BlockHeight: 1, Hash: "0x01", Status: "orphaned"},
+		{BlockHeight: 5, Hash: "", Status: "pending"},
+		{BlockHeight: 2, Hash: "0x02", Status: "Orphaned"},
+		{BlockHeight: 3, Hash: "0x03", Status: "confirmed"},
+		{BlockHeight: 4, Hash: "0x04", Status: "pending"},
+		{BlockHeight: 3, Hash: "0x3b", Status: "pending"},
+	}
+	added, orphaned := DiffBlocks(prev, curr)
+	assert.Equal(t, []*Block{curr[1], curr[4], curr[5]}, added)
+	assert.Equal(t, []*Block{curr[2]}, orphaned)
+
+	// a pending block getting its hash is not a new block
+	added, orphaned = DiffBlocks(curr, []*Block{{BlockHeight: 5, Hash: "0x05", Status: "pending"}})
+	assert.Empty(t, added)
+	assert.Empty(t, orphaned)
+
+	added, orphaned = DiffBlocks(nil, prev)
+	assert.Equal(t, prev, added)
+	assert.Empty(t, orphaned)
+}
+
+func TestPoolRaw(t *testing.T) {
+	raw, code, err := newClient().GetPoolRaw(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var pool map[string]any
+	assert.NoError(t, json.Unmarshal(raw, &pool))
+	assert.Equal(t, "eth", pool["id"])
+	// fields that are not modeled are still available
+	assert.Contains(t, pool["coin"], "market")
+
+	_, code, err = newClient().GetPoolRaw(context.Background(), "mock")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func TestPoolExists(t *testing.T) {
+	var methods []string
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/eth", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+	})
+	handler.HandleFunc("/api/pools/gethonly", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	handler.HandleFunc("/api/pools/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	ok, err := client.PoolExists(ctx, "eth")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{http.MethodHead}, methods)
+
+	methods = nil
+	ok, err = client.PoolExists(ctx, "gethonly")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{http.MethodHead, http.MethodGet}, methods)
+
+	ok, err = client.PoolExists(ctx, "missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = client.PoolExists(ctx, "broken")
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestNetworkHistory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"stats": [
+			{"poolHashrate": 5, "networkHashrate": 300, "networkDifficulty": 30, "created": "2022-06-20T14:00:00Z"},
+			{"poolHashrate": 5, "networkHashrate": 0, "networkDifficulty": 0, "created": "2022-06-20T13:00:00Z"},
+			{"poolHashrate": 5, "networkHashrate": 100, "networkDifficulty": 10, "created": "2022-06-20T12:00:00Z"},
+			{"poolHashrate": 5, "networkHashrate": 200, "networkDifficulty": 20, "created": "2022-06-20T12:30:00+00:00"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	samples, code, err := New(srv.URL).GetNetworkHistory(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, len(samples))
+	assert.Equal(t, 100.0, samples[0].NetworkHashrate)
+	assert.Equal(t, 20.0, samples[1].NetworkDifficulty)
+	assert.Equal(t, "2022-06-20T14:00:00Z", samples[2].Created)
+}
+
+func TestEstimateDailyEarnings(t *testing.T) {
+	// 1% of the network, 100 blocks of 2 coins per day, 1% pool fee
+	assert.InDelta(t, 1.98, float64(EstimateDailyEarnings(1, 100, 2, 100, 1)), 1e-9)
+	assert.InDelta(t, 2.0, float64(EstimateDailyEarnings(1, 100, 2, 100, 0)), 1e-9)
+	// a miner can't find more than all blocks
+	assert.InDelta(t, 200.0, float64(EstimateDailyEarnings(200, 100, 2, 100, 0)), 1e-9)
+	assert.Equal(t, Amount(0), EstimateDailyEarnings(1, 0, 2, 100, 1))
+	assert.Equal(t, Amount(0), EstimateDailyEarnings(0, 100, 2, 100, 1))
+
+	earnings, err := newClient().EstimateMinerDailyEarnings(context.Background(), "eth", testMiner)
+	assert.NoError(t, err)
+	// 2e8 H/s of 938201090362901.9 H/s, a block every 13s, 2.05 coins of the latest confirmed block
+	assert.InDelta(t, 0.0028753684832218316, float64(earnings), 1e-12)
+}
+
+func TestEstimateMinerDailyEarningsUnknownBlockTime(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pool": {"id": "eth", "coin": {"type": "ETH"}, "networkStats": {"networkHashrate": 100}}}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).EstimateMinerDailyEarnings(context.Background(), "eth", testMiner)
+	assert.ErrorIs(t, err, ErrUnknownBlockTime)
+}
+
+func TestWatchPoolStats(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hashrate := 2000
+		switch atomic.AddInt32(&polls, 1) {
+		case 1:
+			hashrate = 1000
+		case 2:
+			hashrate = 1005 // below the threshold
+		}
+		fmt.Fprintf(w, `{"pool": {"id": "eth", "poolStats": {"connectedMiners": 1, "poolHashrate": %d}, "networkStats": {"blockHeight": 10}}}`, hashrate)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := New(srv.URL).WatchPoolStats(ctx, "eth", 5*time.Millisecond)
+	assert.NoError(t, err)
+	ev := <-events
+	assert.NoError(t, ev.Err)
+	assert.Equal(t, int32(1), ev.ConnectedMiners)
+	assert.Equal(t, int64(1000), ev.PoolHashrate)
+	assert.Equal(t, int64(10), ev.BlockHeight)
+	assert.Equal(t, "eth", ev.Pool.ID)
+
+	ev = <-events
+	assert.Equal(t, int64(2000), ev.PoolHashrate)
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&polls), int32(3))
+
+	cancel()
+	for range events {
+	}
+
+	_, err = New(srv.URL).WatchPoolStats(ctx, "eth", 0)
+	assert.Error(t, err)
+}
+
+func TestWatchPoolStatsCoalesce(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&polls, 1)
+		if n == 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"pool": {"id": "eth", "poolStats": {"connectedMiners": %d}}}`, n)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := New(srv.URL).WatchPoolStats(ctx, "eth", 5*time.Millisecond)
+	assert.NoError(t, err)
+	ev := <-events
+	assert.NoError(t, ev.Err)
+	ev = <-events
+	assert.NoError(t, ev.Err)
+	assert.Equal(t, int32(2), ev.ConnectedMiners)
+	ev = <-events
+	assert.Error(t, ev.Err)
+	assert.Nil(t, ev.Pool)
+
+	// the events of polls made while not receiving are replaced by the latest one
+	time.Sleep(50 * time.Millisecond)
+	ev = <-events
+	assert.NoError(t, ev.Err)
+	assert.Greater(t, ev.ConnectedMiners, int32(5))
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchPoolStatsErrorKeepsChange(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&polls, 1)
+		if n > 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"pool": {"id": "eth", "poolStats": {"connectedMiners": %d}}}`, n)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a failed poll while a change is pending delivers both, the change first
+	events, err := New(srv.URL).WatchPoolStats(ctx, "eth", 5*time.Millisecond)
+	assert.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	ev := <-events
+	assert.NoError(t, ev.Err)
+	assert.Equal(t, int32(2), ev.ConnectedMiners)
+	ev = <-events
+	assert.Error(t, ev.Err)
+	cancel()
+	for range events {
+	}
+}
+
+func TestTotalReportedHashrate(t *testing.T) {
+	var requests int
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/eth/miners", minersHandler(250, &requests))
+	handler.HandleFunc("/api/pools/eth", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pool": {"id": "eth", "poolStats": {"poolHashrate": 30000}}}`))
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	total, poolReported, err := New(srv.URL).TotalReportedHashrate(context.Background(), "eth")
+	assert.NoError(t, err)
+	// 250 miners with a hashrate of 1 to 250
+	assert.Equal(t, 31375.0, total)
+	assert.Equal(t, 30000.0, poolReported)
+	assert.Equal(t, 3, requests)
+
+	// a partial sum over the first maxMinerPages pages is not returned
+	handler.HandleFunc("/api/pools/big/miners", minersHandler(maxMinerPages+1, &requests))
+	handler.HandleFunc("/api/pools/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pool": {"id": "big"}}`))
+	})
+	requests = 0
+	_, _, err = New(srv.URL, WithMaxPageSize(1)).TotalReportedHashrate(context.Background(), "big")
+	assert.ErrorIs(t, err, ErrTooManyPages)
+	assert.Equal(t, maxMinerPages, requests)
+
+	_, _, err = New(srv.URL).TotalReportedHashrate(context.Background(), "btc")
+	assert.Error(t, err)
+}
+
+func TestPagingHelpersMaxPageSize(t *testing.T) {
+	const days, blocks = 93, 30
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := func(r *http.Request, total int) (from, to int, pageCount int64) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		from, to = page*pageSize, (page+1)*pageSize
+		if to > total {
+			to = total
+		}
+		return from, to, int64((total + pageSize - 1) / pageSize)
+	}
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/v2/pools/eth/miners/"+testMiner+"/earnings/daily", func(w http.ResponseWriter, r *http.Request) {
+		from, to, pageCount := page(r, days)
+		res := DailyEarningRes{Meta: &Meta{PageCount: pageCount, Success: true}, Result: []*DailyEarning{}}
+		// newest first
+		for i := from; i < to; i++ {
+			res.Result = append(res.Result, &DailyEarning{Amount: 1, Date: start.AddDate(0, 0, days-1-i).Format(time.RFC3339)})
+		}
+		json.NewEncoder(w).Encode(res) // nolint:errcheck
+	})
+	var blockRequests int
+	handler.HandleFunc("/api/v2/pools/eth/blocks", func(w http.ResponseWriter, r *http.Request) {
+		blockRequests++
+		from, to, pageCount := page(r, blocks)
+		res := BlocksRes{Meta: &Meta{PageCount: pageCount, Success: true}, Result: []*Block{}}
+		for i := from; i < to; i++ {
+			// the 25 newest blocks have an effort of 1, the older ones of 2
+			effort := 1.0
+			if i >= 25 {
+				effort = 2
+			}
+			res.Result = append(res.Result, &Block{BlockHeight: int64(blocks - i), Status: BlockStatusConfirmed, Effort: effort})
+		}
+		json.NewEncoder(w).Encode(res) // nolint:errcheck
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	ctx := context.Background()
+	end := start.AddDate(0, 0, days-1)
+
+	for _, client := range []*Client{New(srv.URL), New(srv.URL, WithMaxPageSize(50)), New(srv.URL, WithMaxPageSize(7))} {
+		series, err := client.GetMinerEarningsSeries(ctx, "eth", testMiner, start, end, false)
+		assert.NoError(t, err)
+		assert.Equal(t, days, len(series))
+	}
+
+	// the last n blocks are averaged even if n exceeds the maximum page size
+	luck, err := New(srv.URL, WithMaxPageSize(10)).PoolLuck(ctx, "eth", 25)
+	assert.NoError(t, err)
+	assert.InDelta(t, 100, luck, 1e-9)
+	assert.Equal(t, 3, blockRequests)
+
+	luck, err = New(srv.URL, WithMaxPageSize(10)).PoolLuck(ctx, "eth", 100)
+	assert.NoError(t, err)
+	assert.InDelta(t, 3500.0/30, luck, 1e-9)
+}
+
+func TestMinerEarningsSeriesTimeZone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pageCount": 1, "result": [
+			{"amount": 0.4, "date": "2022-06-20T22:30:00Z"},
+			{"amount": 0.3, "date": "2022-06-20T01:00:00Z"},
+			{"amount": 0.2, "date": "2022-06-19T23:30:00Z"},
+			{"amount": 0.1, "date": "2022-06-19T12:00:00Z"}
+		]}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	// by UTC day the earnings before and after midnight are on different days
+	from := time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 6, 21, 0, 0, 0, 0, time.UTC)
+	series, err := New(srv.URL).GetMinerEarningsSeries(ctx, "eth", testMiner, from, to, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(series))
+	assert.InDelta(t, 0.3, series[0].Amount, 1e-9)
+	assert.InDelta(t, 0.7, series[1].Amount, 1e-9)
+
+	// two hours east of UTC the earnings around UTC midnight are on the same local day
+	east := time.FixedZone("UTC+2", 2*60*60)
+	client := New(srv.URL, WithTimeZone(east))
+	series, err = client.GetMinerEarningsSeries(ctx, "eth", testMiner, from.In(east), to.In(east), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(series))
+	assert.InDelta(t, 0.1, series[0].Amount, 1e-9)
+	assert.InDelta(t, 0.5, series[1].Amount, 1e-9)
+	assert.Equal(t, "2022-06-20T00:00:00+02:00", series[1].Date)
+	assert.InDelta(t, 0.4, series[2].Amount, 1e-9)
+	assert.Equal(t, "2022-06-21T00:00:00+02:00", series[2].Date)
+
+	// west of UTC the day boundary moves the other way
+	west := time.FixedZone("UTC-3", -3*60*60)
+	series, err = New(srv.URL, WithTimeZone(west)).GetMinerEarningsSeries(ctx, "eth", testMiner, from.In(west), to.In(west), true)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(series))
+	assert.Equal(t, "2022-06-18T00:00:00-03:00", series[0].Date)
+	assert.Equal(t, 0.0, series[0].Amount)
+	assert.InDelta(t, 0.6, series[1].Amount, 1e-9)
+	assert.InDelta(t, 0.4, series[2].Amount, 1e-9)
+
+	ts, err := client.ParseTime("2022-06-19T23:30:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, 20, ts.Day())
+	assert.Equal(t, east, ts.Location())
+	_, err = client.ParseTime("yesterday")
+	assert.Error(t, err)
+}
+
+func TestEffectivePoolFee(t *testing.T) {
+	fee, err := newClient().EffectivePoolFee(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, fee)
+
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+
+	tests := []struct {
+		pool string
+		want float64
+	}{
+		{`{"poolFeePercent": 1.5, "devFeePercent": 0.5, "donationsPercent": 0.1}`, 2.1},
+		{`{"poolFeePercent": 0, "donationsPercent": 0.1}`, 0.1},
+		{`{"poolFeePercent": 1, "devFeePercent": -1}`, 1},
+		{`{"poolFeePercent": 90, "devFeePercent": 20}`, 100},
+		{`{}`, 0},
+	}
+	for _, tt := range tests {
+		body = `{"pool": ` + tt.pool + `}`
+		fee, err := client.EffectivePoolFee(context.Background(), "eth")
+		assert.NoError(t, err)
+		assert.InDelta(t, tt.want, fee, 1e-9, tt.pool)
+	}
+
+	_, err = newClient().EffectivePoolFee(context.Background(), "mock")
+	assert.Error(t, err)
+}
+
+func TestGetBanningConfig(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+
+	tests := []struct {
+		pool string
+		want BanningConfig
+	}{
+		{`{"banning": {"enabled": true, "checkThreshold": "100", "invalidPercent": 25, "time": 300}}`, BanningConfig{true, 100, 25, 300}},
+		{`{"shareBasedBanning": {"enabled": true, "checkThreshold": 50, "invalidPercent": 50, "time": 600}}`, BanningConfig{true, 50, 50, 600}},
+		{`{"banning": {"enabled": false}}`, BanningConfig{}},
+		{`{"banning": null}`, BanningConfig{}},
+		{`{}`, BanningConfig{}},
+	}
+	for _, tt := range tests {
+		body = `{"pool": ` + tt.pool + `}`
+		cfg, err := client.GetBanningConfig(context.Background(), "eth")
+		assert.NoError(t, err)
+		assert.Equal(t, &tt.want, cfg, tt.pool)
+	}
+
+	_, err := newClient().GetBanningConfig(context.Background(), "mock")
+	assert.Error(t, err)
+}
+
+func TestGetMinerAcrossPools(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pools": [
+			{"id": "eth1", "coin": {"symbol": "ETH"}},
+			{"id": "eth2", "coin": {"symbol": "ETH"}},
+			{"id": "etc", "coin": {"symbol": "ETC"}},
+			{"id": "empty", "coin": {"symbol": "ETH"}}
+		]}`))
+	})
+	for id, balance := range map[string]string{"eth1": "0.5", "eth2": "0.25", "etc": "3"} {
+		body := `{"pendingBalance": ` + balance + `}`
+		handler.HandleFunc("/api/pools/"+id+"/miners/"+testMiner, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		})
+	}
+	handler.HandleFunc("/api/pools/empty/miners/"+testMiner, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Miner not found"}`))
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	client := New(srv.URL)
+	ctx := context.Background()
+
+	miners, pending, err := client.GetMinerAcrossPools(ctx, []string{"eth1", "eth2", "etc", "empty"}, testMiner)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(miners))
+	assert.Equal(t, 0.25, miners["eth2"].PendingBalance)
+	m, ok := miners["empty"]
+	assert.True(t, ok)
+	assert.Nil(t, m)
+	assert.Equal(t, map[string]Amount{"ETH": 0.75, "ETC": 3}, pending)
+
+	// pools that don't exist fail the batch
+	_, _, err = client.GetMinerAcrossPools(ctx, []string{"eth1", "btc"}, testMiner)
+	assert.True(t, IsNotFound(err))
+
+	miners, pending, err = client.GetMinerAcrossPools(ctx, nil, testMiner)
+	assert.NoError(t, err)
+	assert.Empty(t, miners)
+	assert.Empty(t, pending)
+}
+
+func TestFanOutCancellation(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	release := make(chan struct{})
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pools": [{"id": "slow"}, {"id": "broken"}]}`))
+	})
+	blocking := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}
+	handler.HandleFunc("/api/pools/slow", blocking)
+	handler.HandleFunc("/api/pools/slow/", blocking)
+	handler.HandleFunc("/api/v2/pools/slow/", blocking)
+	failing := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	handler.HandleFunc("/api/pools/broken/miners/"+testMiner, failing)
+	handler.HandleFunc("/api/pools/half", failing)
+	handler.HandleFunc("/api/pools/half/miners/"+testMiner, blocking)
+	srv := httptest.NewServer(handler)
+	client := New(srv.URL, WithDisableKeepAlives())
+	ctx := context.Background()
+	status := func(err error) int {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return apiErr.StatusCode
+		}
+		return 0
+	}
+
+	// a failing request cancels the pending ones
+	start := time.Now()
+	_, _, err := client.GetMinerAcrossPools(ctx, []string{"slow", "broken", "slow"}, testMiner)
+	assert.Equal(t, http.StatusInternalServerError, status(err))
+	_, err = client.MinerPoolShare(ctx, "half", testMiner)
+	assert.Equal(t, http.StatusInternalServerError, status(err))
+
+	// canceling the context ends all requests
+	cctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	_, err = client.GetMinerDashboard(cctx, "slow", testMiner)
+	cancel()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	close(release)
+	srv.Close()
+	// assert.Eventually would count its own goroutine
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), baseline, "leaked goroutines")
+}
+
+func TestCompositeTimeout(t *testing.T) {
+	release := make(chan struct{})
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/pools/eth/miners/"+testMiner, fileReq("testdata/miner_eth.json"))
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}
+	handler.HandleFunc("/api/v2/pools/eth/miners/"+testMiner+"/payments", slow)
+	handler.HandleFunc("/api/v2/pools/eth/miners/"+testMiner+"/earnings/daily", slow)
+	handler.HandleFunc("/api/pools/eth", slow)
+	handler.HandleFunc("/api/v2/pools/eth/blocks", slow)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	defer close(release)
+	ctx := context.Background()
+
+	// the slow parts use up the budget while the fast one is still returned
+	client := New(srv.URL, WithCompositeTimeout(100*time.Millisecond))
+	start := time.Now()
+	d, err := client.GetMinerDashboard(ctx, "eth", testMiner)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.NotNil(t, d.Miner)
+	assert.ErrorIs(t, d.Errors[DashboardPayments], context.DeadlineExceeded)
+	assert.ErrorIs(t, d.Errors[DashboardEarnings], context.DeadlineExceeded)
+
+	start = time.Now()
+	_, err = client.MinerPoolShare(ctx, "eth", testMiner)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// paging helpers share the budget as well
+	start = time.Now()
+	_, _, err = client.TotalBlockRewards(ctx, "eth", false)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	err = client.ExportMinerPaymentsCSVWithCoin(ctx, "eth", testMiner, Coin{}, io.Discard)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// a tighter deadline of the parent context wins
+	client = New(srv.URL, WithCompositeTimeout(time.Minute))
+	tight, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	_, err = client.MinerPoolShare(tight, "eth", testMiner)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
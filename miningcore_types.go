@@ -1,17 +1,44 @@
 package miningcore
 
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+)
+
+// miningcore reports missing values, such as the last payment of a miner that was never paid, as null.
+// All models decode null to the zero value of a field: pointers, slices and maps are nil,
+// strings are empty and numbers, including Amount, are 0. A string that is empty is therefore
+// indistinguishable from null, use the pointer fields being nil to detect missing objects.
+// The exception is the status of a block, where null decodes to BlockStatusUnknown like any other unexpected value.
+//
+// Some coins and proxies serialize integers such as block heights as JSON strings. The integer fields
+// of the models accept both. So do the pagination fields of Meta, which are decoded by the paginated
+// responses embedding it, a method of Meta would be promoted and take over decoding of the result.
+
+// Amount is an amount of coins as reported by the API.
+type Amount float64
+
 type Meta struct {
 	PageCount           int64    `json:"pageCount"`
 	Success             bool     `json:"success"`
 	ResponseMessageType int64    `json:"responseMessageType,omitempty"`
 	ResponseMessageID   string   `json:"responseMessageId,omitempty"`
 	ResponseMessageArgs []string `json:"responseMessageArgs,omitempty"`
+	NextPageToken       string   `json:"nextPageToken,omitempty"`
+}
+
+// Page is a single page of a paginated result.
+type Page[T any] struct {
+	Items []T
+	// Total is the number of items across all pages, or -1 if the server did not report it.
+	Total int64
 }
 
 type PoolInfo struct {
 	ID                      string                          `json:"id"`
 	Coin                    *APICoinConfig                  `json:"coin"`
-	Ports                   map[string]PoolEndpoint         `json:"ports"`
+	Ports                   PoolPorts                       `json:"ports"`
 	PaymentProcessing       *APIPoolPaymentProcessingConfig `json:"paymentProcessing"`
 	ShareBasedBanning       *PoolShareBasedBanningConfig    `json:"shareBasedBanning"`
 	ClientConnectionTimeout int32                           `json:"clientConnectionTimeout"`
@@ -27,22 +54,105 @@ type PoolInfo struct {
 	TotalBlocks             int32                           `json:"totalBlocks"`
 	LastPoolBlockTime       string                          `json:"lastPoolBlockTime"`
 	APIEndpoint             string                          `json:"apiEndpoint"`
+	// DevFeePercent and DonationPercent are fees on top of PoolFeePercent reported by some miningcore versions
+	// and forks, 0 if not reported.
+	DevFeePercent   float64 `json:"devFeePercent,omitempty"`
+	DonationPercent float64 `json:"donationsPercent,omitempty"`
+	// Banning is the banning section reported by some deployments instead of shareBasedBanning, nil if absent.
+	// Use GetBanningConfig to read whichever is present.
+	Banning *BanningConfig `json:"banning,omitempty"`
+}
+
+// UnmarshalJSON decodes the pool, accepting integers serialized as strings.
+func (p *PoolInfo) UnmarshalJSON(data []byte) error {
+	type poolInfo PoolInfo
+	return unmarshalLenient(data, (*poolInfo)(p))
+}
+
+// AdminPool is a pool as returned by the admin API, including operational fields that are not public.
+type AdminPool struct {
+	PoolInfo
+	LastBlockTime         string  `json:"lastBlockTime"`
+	TotalPendingBalance   float64 `json:"totalPendingBalance"`
+	PendingBlocks         int32   `json:"pendingBlocks"`
+	ProcessingStatus      string  `json:"processingStatus"`
+	LastPaymentProcessing string  `json:"lastPaymentProcessing"`
+}
+
+// UnmarshalJSON decodes the pool, accepting integers serialized as strings.
+func (p *AdminPool) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &p.PoolInfo); err != nil {
+		return err
+	}
+	// the methods of PoolInfo are promoted to any type embedding it, so the admin fields are decoded on their own
+	var admin struct {
+		LastBlockTime         string  `json:"lastBlockTime"`
+		TotalPendingBalance   float64 `json:"totalPendingBalance"`
+		PendingBlocks         int32   `json:"pendingBlocks"`
+		ProcessingStatus      string  `json:"processingStatus"`
+		LastPaymentProcessing string  `json:"lastPaymentProcessing"`
+	}
+	if err := unmarshalLenient(data, &admin); err != nil {
+		return err
+	}
+	p.LastBlockTime = admin.LastBlockTime
+	p.TotalPendingBalance = admin.TotalPendingBalance
+	p.PendingBlocks = admin.PendingBlocks
+	p.ProcessingStatus = admin.ProcessingStatus
+	p.LastPaymentProcessing = admin.LastPaymentProcessing
+	return nil
 }
 
 type APICoinConfig struct {
-	Type          string `json:"type"`
-	Name          string `json:"name"`
-	Symbol        string `json:"symbol"`
-	Website       string `json:"website"`
-	Family        string `json:"family"`
-	Algorithm     string `json:"algorithm"`
-	Twitter       string `json:"twitter"`
-	Discord       string `json:"discord"`
-	Telegram      string `json:"telegram"`
-	CanonicalName string `json:"canonicalName"`
+	Type          string     `json:"type"`
+	Name          string     `json:"name"`
+	Symbol        string     `json:"symbol"`
+	Website       string     `json:"website"`
+	Family        CoinFamily `json:"family"`
+	Algorithm     Algorithm  `json:"algorithm"`
+	Twitter       string     `json:"twitter"`
+	Discord       string     `json:"discord"`
+	Telegram      string     `json:"telegram"`
+	CanonicalName string     `json:"canonicalName"`
+	// BlockTime is the target block time in seconds. It is not reported by every miningcore version and 0 if unknown.
+	BlockTime float64 `json:"blockTime,omitempty"`
+	// ExplorerTxLink is the explorer URL template of transactions from the coin template, with {0} as
+	// placeholder for the transaction ID. The pool API doesn't report it, set it to build receipts of payments
+	// without a transaction link.
+	ExplorerTxLink string `json:"explorerTxLink,omitempty"`
+	// Decimals is the number of decimal places of the smallest unit of the coin, e.g. 8 for satoshis or 18 for wei.
+	// The pool API doesn't report it, set it to convert amounts in base units with Amount.ToWholeCoins.
+	Decimals int `json:"decimals,omitempty"`
+}
+
+// Coin is the configuration of the coin of a pool.
+type Coin = APICoinConfig
+
+// PoolPorts are the stratum ports of a pool by port number.
+type PoolPorts map[string]PoolEndpoint
+
+// UnmarshalJSON decodes the ports and sets the Port of every endpoint from its key.
+func (p *PoolPorts) UnmarshalJSON(data []byte) error {
+	var ports map[string]PoolEndpoint
+	if err := json.Unmarshal(data, &ports); err != nil {
+		return err
+	}
+	for k, e := range ports {
+		if port, err := strconv.Atoi(k); err == nil {
+			e.Port = port
+			ports[k] = e
+		}
+	}
+	*p = ports
+	return nil
 }
 
+// PoolPortConfig is the configuration of a stratum port of a pool.
+type PoolPortConfig = PoolEndpoint
+
 type PoolEndpoint struct {
+	// Port is the port number, taken from the key in PoolInfo.Ports.
+	Port             int                     `json:"-"`
 	ListenAddress    string                  `json:"listenAddress"`
 	Name             string                  `json:"name"`
 	Difficulty       float64                 `json:"difficulty"`
@@ -54,6 +164,19 @@ type PoolEndpoint struct {
 	TLSPfxPassword   string                  `json:"tlsPfxPassword"`
 }
 
+// StratumURL returns the URL miners use to connect to the port on host,
+// stratum+ssl:// for TLS ports and stratum+tcp:// otherwise.
+func (p PoolEndpoint) StratumURL(host string) string {
+	scheme := "stratum+tcp"
+	if p.TLS {
+		scheme = "stratum+ssl"
+	}
+	if p.Port > 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(p.Port))
+	}
+	return scheme + "://" + host
+}
+
 type TCPProxyProtocolConfig struct {
 	Enable         bool     `json:"enable"`
 	Mandatory      bool     `json:"mandatory"`
@@ -70,10 +193,45 @@ type VarDiffConfig struct {
 }
 
 type APIPoolPaymentProcessingConfig struct {
-	Enabled        bool                   `json:"enabled"`
-	MinimumPayment float64                `json:"minimumPayment"`
-	PayoutScheme   string                 `json:"payoutScheme"`
-	Extra          map[string]interface{} `json:"extra"`
+	Enabled            bool                   `json:"enabled"`
+	MinimumPayment     float64                `json:"minimumPayment"`
+	PayoutScheme       string                 `json:"payoutScheme"`
+	PayoutSchemeConfig *PayoutSchemeConfig    `json:"payoutSchemeConfig"`
+	Extra              map[string]interface{} `json:"extra"`
+}
+
+// PayoutScheme describes how a pool pays its miners.
+type PayoutScheme struct {
+	Enabled        bool                `json:"enabled"`
+	Scheme         string              `json:"payoutScheme"`
+	MinimumPayment float64             `json:"minimumPayment"`
+	Config         *PayoutSchemeConfig `json:"payoutSchemeConfig"`
+}
+
+// PayoutSchemeConfig holds the scheme specific settings, such as the PPLNS window factor.
+// Keys that are not modeled are kept in Extra.
+type PayoutSchemeConfig struct {
+	Factor float64                    `json:"factor"`
+	Extra  map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the known keys and keeps the rest in Extra.
+// miningcore serializes an empty config as nested arrays, anything that is not an object is therefore ignored.
+func (p *PayoutSchemeConfig) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil // nolint:nilerr
+	}
+	if v, ok := raw["factor"]; ok {
+		if err := json.Unmarshal(v, &p.Factor); err != nil {
+			return err
+		}
+		delete(raw, "factor")
+	}
+	if len(raw) > 0 {
+		p.Extra = raw
+	}
+	return nil
 }
 
 type PoolShareBasedBanningConfig struct {
@@ -83,6 +241,46 @@ type PoolShareBasedBanningConfig struct {
 	Time            int32   `json:"time"`
 }
 
+// UnmarshalJSON decodes the config, accepting integers serialized as strings.
+func (p *PoolShareBasedBanningConfig) UnmarshalJSON(data []byte) error {
+	type banningConfig PoolShareBasedBanningConfig
+	return unmarshalLenient(data, (*banningConfig)(p))
+}
+
+// BanningConfig is the policy for banning miners that submit too many invalid shares.
+// Miners are checked after CheckThreshold shares and banned for Time seconds if more than
+// InvalidPercent of them were invalid.
+type BanningConfig struct {
+	Enabled        bool    `json:"enabled"`
+	CheckThreshold int32   `json:"checkThreshold"`
+	InvalidPercent float64 `json:"invalidPercent"`
+	Time           int32   `json:"time"`
+}
+
+// UnmarshalJSON decodes the config, accepting integers serialized as strings.
+func (b *BanningConfig) UnmarshalJSON(data []byte) error {
+	type banningConfig BanningConfig
+	return unmarshalLenient(data, (*banningConfig)(b))
+}
+
+// BanningConfig returns the banning policy of the pool from the banning or the shareBasedBanning section,
+// or a zero config with Enabled false if the pool reports neither.
+func (p *PoolInfo) BanningConfig() *BanningConfig {
+	switch {
+	case p.Banning != nil:
+		b := *p.Banning
+		return &b
+	case p.ShareBasedBanning != nil:
+		return &BanningConfig{
+			Enabled:        p.ShareBasedBanning.Enabeld,
+			CheckThreshold: p.ShareBasedBanning.CheckThresghold,
+			InvalidPercent: p.ShareBasedBanning.InvalidPercent,
+			Time:           p.ShareBasedBanning.Time,
+		}
+	}
+	return &BanningConfig{}
+}
+
 type PoolStats struct {
 	LastPoolBlockTime string `json:"lastPoolBlockTime"`
 	ConnectedMiners   int32  `json:"connectedMiners"`
@@ -90,6 +288,12 @@ type PoolStats struct {
 	SharesPerSecond   int32  `json:"sharesPerSecond"`
 }
 
+// UnmarshalJSON decodes the stats, accepting integers serialized as strings.
+func (p *PoolStats) UnmarshalJSON(data []byte) error {
+	type poolStats PoolStats
+	return unmarshalLenient(data, (*poolStats)(p))
+}
+
 type BlockchainStats struct {
 	NetworkType          string  `json:"networkType"`
 	NetworkHashrate      float64 `json:"networkHashrate"`
@@ -102,6 +306,12 @@ type BlockchainStats struct {
 	RewardType           string  `json:"rewardType"`
 }
 
+// UnmarshalJSON decodes the stats, accepting integers serialized as strings.
+func (b *BlockchainStats) UnmarshalJSON(data []byte) error {
+	type blockchainStats BlockchainStats
+	return unmarshalLenient(data, (*blockchainStats)(b))
+}
+
 type MinerPerformanceStats struct {
 	Miner           string  `json:"miner"`
 	Hashrate        float64 `json:"hashrate"`
@@ -109,20 +319,26 @@ type MinerPerformanceStats struct {
 }
 
 type Block struct {
-	PoolID                      string  `json:"poolId"`
-	BlockHeight                 int64   `json:"blockHeight"`
-	NetworkDifficulty           float64 `json:"networkDifficulty"`
-	Status                      string  `json:"status"`
-	Type                        string  `json:"type"`
-	ConfirmationProgress        float64 `json:"confirmationProgress"`
-	Effort                      float64 `json:"effort"`
-	TransactionConfirmationData string  `json:"transactionConfirmationData"`
-	Reward                      float64 `json:"reward"`
-	InfoLink                    string  `json:"infoLink"`
-	Hash                        string  `json:"hash"`
-	Miner                       string  `json:"miner"`
-	Source                      string  `json:"source"`
-	Created                     string  `json:"created"`
+	PoolID                      string      `json:"poolId"`
+	BlockHeight                 int64       `json:"blockHeight"`
+	NetworkDifficulty           float64     `json:"networkDifficulty"`
+	Status                      BlockStatus `json:"status"`
+	Type                        string      `json:"type"`
+	ConfirmationProgress        float64     `json:"confirmationProgress"`
+	Effort                      float64     `json:"effort"`
+	TransactionConfirmationData string      `json:"transactionConfirmationData"`
+	Reward                      float64     `json:"reward"`
+	InfoLink                    string      `json:"infoLink"`
+	Hash                        string      `json:"hash"`
+	Miner                       string      `json:"miner"`
+	Source                      string      `json:"source"`
+	Created                     string      `json:"created"`
+}
+
+// UnmarshalJSON decodes the block, accepting integers serialized as strings.
+func (b *Block) UnmarshalJSON(data []byte) error {
+	type block Block
+	return unmarshalLenient(data, (*block)(b))
 }
 
 type BlocksRes struct {
@@ -130,6 +346,12 @@ type BlocksRes struct {
 	Result []*Block `json:"result"`
 }
 
+// UnmarshalJSON decodes the page of blocks, accepting integers serialized as strings.
+func (r *BlocksRes) UnmarshalJSON(data []byte) error {
+	type blocksRes BlocksRes
+	return unmarshalLenient(data, (*blocksRes)(r))
+}
+
 type Payment struct {
 	Coin                        string  `json:"coin,omitempty"`
 	Address                     string  `json:"address,omitempty"`
@@ -145,6 +367,12 @@ type PaymentRes struct {
 	Result []*Payment `json:"result"`
 }
 
+// UnmarshalJSON decodes the page of payments, accepting integers serialized as strings.
+func (r *PaymentRes) UnmarshalJSON(data []byte) error {
+	type paymentRes PaymentRes
+	return unmarshalLenient(data, (*paymentRes)(r))
+}
+
 type MinerStats struct {
 	PendingShares      int64          `json:"pendingShares"`
 	PendingBalance     float64        `json:"pendingBalance"`
@@ -156,6 +384,39 @@ type MinerStats struct {
 	PerformanceSamples []*WorkerStats `json:"performanceSamples"`
 }
 
+// UnmarshalJSON decodes the stats, accepting integers serialized as strings.
+func (m *MinerStats) UnmarshalJSON(data []byte) error {
+	type minerStats MinerStats
+	return unmarshalLenient(data, (*minerStats)(m))
+}
+
+// DefaultWorker is the name miningcore uses for the worker of miners that don't set a worker name.
+const DefaultWorker = ""
+
+// Worker returns the current performance of the named worker.
+// Use DefaultWorker for miners that don't set a worker name.
+func (m *MinerStats) Worker(name string) (*WorkerPerformanceStats, bool) {
+	if m == nil || m.Performance == nil {
+		return nil, false
+	}
+	w, ok := m.Performance.Workers[name]
+	return w, ok && w != nil
+}
+
+// Hashrate returns the current hashrate of the miner summed over all workers.
+func (m *MinerStats) Hashrate() float64 {
+	if m == nil || m.Performance == nil {
+		return 0
+	}
+	var sum float64
+	for _, w := range m.Performance.Workers {
+		if w != nil {
+			sum += w.Hashrate
+		}
+	}
+	return sum
+}
+
 type WorkerStats struct {
 	Created string                             `json:"created"`
 	Workers map[string]*WorkerPerformanceStats `json:"workers"`
@@ -177,6 +438,12 @@ type DailyEarningRes struct {
 	Result []*DailyEarning `json:"result"`
 }
 
+// UnmarshalJSON decodes the page of daily earnings, accepting integers serialized as strings.
+func (r *DailyEarningRes) UnmarshalJSON(data []byte) error {
+	type dailyEarningRes DailyEarningRes
+	return unmarshalLenient(data, (*dailyEarningRes)(r))
+}
+
 type BalanceChange struct {
 	PoolID  string  `json:"poolId"`
 	Address string  `json:"address"`
@@ -190,6 +457,12 @@ type BalanceChangeRes struct {
 	Result []*BalanceChange `json:"result"`
 }
 
+// UnmarshalJSON decodes the page of balance changes, accepting integers serialized as strings.
+func (r *BalanceChangeRes) UnmarshalJSON(data []byte) error {
+	type balanceChangeRes BalanceChangeRes
+	return unmarshalLenient(data, (*balanceChangeRes)(r))
+}
+
 type PoolPerformance struct {
 	PoolHashrate         float64 `json:"poolHashrate"`
 	ConnectedMiners      int32   `json:"connectedMiners"`
@@ -199,6 +472,19 @@ type PoolPerformance struct {
 	Created              string  `json:"created"`
 }
 
+// UnmarshalJSON decodes the sample, accepting integers serialized as strings.
+func (p *PoolPerformance) UnmarshalJSON(data []byte) error {
+	type poolPerformance PoolPerformance
+	return unmarshalLenient(data, (*poolPerformance)(p))
+}
+
+// NetworkSample is the network hashrate and difficulty at the time of a pool performance sample.
+type NetworkSample struct {
+	NetworkHashrate   float64 `json:"networkHashrate"`
+	NetworkDifficulty float64 `json:"networkDifficulty"`
+	Created           string  `json:"created"`
+}
+
 type MinerSettings struct {
 	PaymentThreshold float64 `json:"paymentThreshold"`
 }
@@ -0,0 +1,184 @@
+package miningcore
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nullFields returns a JSON object that sets every field of the struct v points to to null.
+func nullFields(v any) []byte {
+	t := reflect.TypeOf(v).Elem()
+	fields := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, `"`+name+`": null`)
+	}
+	return []byte("{" + strings.Join(fields, ",") + "}")
+}
+
+func TestNullFields(t *testing.T) {
+	// models hold the expected result of decoding null into every field
+	models := []any{
+		&PoolInfo{},
+		&AdminPool{},
+		&APICoinConfig{},
+		&PoolEndpoint{},
+		&APIPoolPaymentProcessingConfig{},
+		&PayoutScheme{},
+		&PayoutSchemeConfig{},
+		&PoolShareBasedBanningConfig{},
+		&BanningConfig{},
+		&PoolStats{},
+		&BlockchainStats{},
+		&MinerPerformanceStats{},
+		// the status of a block is the exception, null is an unknown status
+		&Block{Status: BlockStatusUnknown},
+		&Payment{},
+		&MinerStats{},
+		&WorkerStats{},
+		&WorkerPerformanceStats{},
+		&DailyEarning{},
+		&BalanceChange{},
+		&PoolPerformance{},
+		&NetworkSample{},
+		&MinerSettings{},
+		&Meta{},
+	}
+	for _, m := range models {
+		name := reflect.TypeOf(m).Elem().Name()
+		data := nullFields(m)
+		v := reflect.New(reflect.TypeOf(m).Elem())
+		assert.NoError(t, json.Unmarshal(data, v.Interface()), name)
+		assert.Equal(t, m, v.Interface(), name)
+	}
+}
+
+func TestNullValues(t *testing.T) {
+	data := []byte(`{
+		"pendingShares": 0,
+		"pendingBalance": 0.1,
+		"totalPaid": null,
+		"todayPaid": null,
+		"lastPayment": null,
+		"lastPaymentLink": null,
+		"performance": {"created": "2022-06-20T12:00:00Z", "workers": {"": null, "rig1": {"hashrate": 5}}},
+		"performanceSamples": null
+	}`)
+	var m MinerStats
+	assert.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, 0.1, m.PendingBalance)
+	assert.Empty(t, m.LastPayment)
+	assert.Empty(t, m.LastPaymentLink)
+	assert.Nil(t, m.PerformanceSamples)
+	assert.Equal(t, 5.0, m.Hashrate())
+	_, ok := m.Worker(DefaultWorker)
+	assert.False(t, ok)
+
+	var pool PoolInfo
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "eth", "coin": null, "ports": null, "poolStats": null, "networkStats": null}`), &pool))
+	assert.Nil(t, pool.Coin)
+	assert.Nil(t, pool.PoolStats)
+	assert.Nil(t, pool.NetworkStats)
+}
+
+func TestPoolPorts(t *testing.T) {
+	data, err := os.ReadFile("testdata/ports.json")
+	assert.NoError(t, err)
+	var ports PoolPorts
+	assert.NoError(t, json.Unmarshal(data, &ports))
+	assert.Equal(t, 3, len(ports))
+
+	cpu := ports["3052"]
+	assert.Equal(t, 3052, cpu.Port)
+	assert.Equal(t, "CPU Mining", cpu.Name)
+	assert.False(t, cpu.TLS)
+	assert.Equal(t, 0.01, cpu.VarDiff.MinDiff)
+	assert.Equal(t, 0.0, cpu.VarDiff.MaxDiff)
+	assert.Equal(t, 15.0, cpu.VarDiff.TargetTime)
+	assert.Equal(t, 90.0, cpu.VarDiff.RetargetTime)
+	assert.Equal(t, 30.0, cpu.VarDiff.VariancePercent)
+	assert.Equal(t, "stratum+tcp://pool.example.com:3052", cpu.StratumURL("pool.example.com"))
+
+	assert.Equal(t, 100.0, ports["3053"].VarDiff.MaxDiff)
+
+	tls := ports["3054"]
+	assert.True(t, tls.TLS)
+	assert.Nil(t, tls.VarDiff)
+	assert.Equal(t, "stratum+ssl://pool.example.com:3054", tls.StratumURL("pool.example.com"))
+	assert.Equal(t, "stratum+ssl://[::1]:3054", tls.StratumURL("::1"))
+
+	pool, _, err := newClient().GetPool(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, 420, pool.Ports["420"].Port)
+	assert.Equal(t, "stratum+ssl://eth.example.com:422", pool.Ports["422"].StratumURL("eth.example.com"))
+	assert.Equal(t, "stratum+tcp://eth.example.com", PoolPortConfig{}.StratumURL("eth.example.com"))
+}
+
+func TestIntegersAsStrings(t *testing.T) {
+	data, err := os.ReadFile("testdata/pool_strings.json")
+	assert.NoError(t, err)
+	var res struct {
+		Pool PoolInfo `json:"pool"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &res))
+	pool := res.Pool
+	assert.Equal(t, "erg", pool.ID)
+	assert.Equal(t, int32(600), pool.ClientConnectionTimeout)
+	assert.Equal(t, int32(10), pool.JobRebroadcastTimeout)
+	assert.Equal(t, int32(0), pool.BlockRefreshInterval)
+	assert.Equal(t, int32(17), pool.TotalBlocks)
+	assert.Equal(t, 1234.5, pool.TotalPaid)
+	assert.Equal(t, int32(50), pool.ShareBasedBanning.CheckThresghold)
+	assert.Equal(t, int32(600), pool.ShareBasedBanning.Time)
+	assert.Equal(t, int32(42), pool.PoolStats.ConnectedMiners)
+	assert.Equal(t, int64(123456789), pool.PoolStats.PoolHashrate)
+	assert.Equal(t, int32(3), pool.PoolStats.SharesPerSecond)
+	assert.Equal(t, int64(812345), pool.NetworkStats.BlockHeight)
+	assert.Equal(t, int32(30), pool.NetworkStats.ConnectedPeers)
+	assert.Equal(t, FamilyErgo, pool.Coin.Family)
+
+	var admin AdminPool
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "erg", "totalBlocks": "3", "pendingBlocks": "2", "processingStatus": "idle"}`), &admin))
+	assert.Equal(t, "erg", admin.ID)
+	assert.Equal(t, int32(3), admin.TotalBlocks)
+	assert.Equal(t, int32(2), admin.PendingBlocks)
+	assert.Equal(t, "idle", admin.ProcessingStatus)
+
+	var blocks BlocksRes
+	assert.NoError(t, json.Unmarshal([]byte(`{"pageCount": 1, "result": [
+		{"blockHeight": "812345", "status": "confirmed", "reward": 3},
+		{"blockHeight": 812344, "status": "pending"}
+	]}`), &blocks))
+	assert.Equal(t, int64(1), blocks.PageCount)
+	assert.Equal(t, int64(812345), blocks.Result[0].BlockHeight)
+	assert.Equal(t, BlockStatusConfirmed, blocks.Result[0].Status)
+	assert.Equal(t, int64(812344), blocks.Result[1].BlockHeight)
+
+	var miner MinerStats
+	assert.NoError(t, json.Unmarshal([]byte(`{"pendingShares": "12", "pendingBalance": 0.5}`), &miner))
+	assert.Equal(t, int64(12), miner.PendingShares)
+
+	// keys match case-insensitively, like they do for encoding/json
+	var b Block
+	assert.NoError(t, json.Unmarshal([]byte(`{"BlockHeight": "5"}`), &b))
+	assert.Equal(t, int64(5), b.BlockHeight)
+
+	var payments PaymentRes
+	assert.NoError(t, json.Unmarshal([]byte(`{"pageCount": "3", "success": true, "result": [{"amount": 1}]}`), &payments))
+	assert.Equal(t, int64(3), payments.PageCount)
+	assert.Equal(t, 1.0, payments.Result[0].Amount)
+
+	// strings that don't hold an integer are still rejected
+	var stats PoolStats
+	assert.Error(t, json.Unmarshal([]byte(`{"connectedMiners": "many"}`), &stats))
+	assert.Error(t, json.Unmarshal([]byte(`{"connectedMiners": "1.5"}`), &stats))
+}
@@ -0,0 +1,182 @@
+package miningcore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ErrBlockOrphaned is returned by WaitForBlockConfirmation if the block was orphaned.
+var ErrBlockOrphaned = errors.New("miningcore: block orphaned")
+
+const (
+	// blocksPageSize is the page size used when searching through the blocks of a pool.
+	blocksPageSize = 100
+	// maxBlockPages bounds the number of pages fetched when paging through all blocks of a pool.
+	maxBlockPages = 1000
+)
+
+// WaitForBlockConfirmation polls the blocks of a pool every pollInterval until the block at the given height
+// is confirmed and returns it. A block counts as confirmed once its status is confirmed or its
+// confirmation progress reached 1. If the block gets orphaned, it is returned together with ErrBlockOrphaned.
+// Blocks that are not yet known to the pool are waited for as well.
+func (c *Client) WaitForBlockConfirmation(ctx context.Context, id string, blockHeight uint64, pollInterval time.Duration) (*Block, error) {
+	if pollInterval <= 0 {
+		return nil, errors.New("miningcore: poll interval must be greater than zero")
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		b, err := c.findBlock(ctx, id, blockHeight)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			switch normalizeBlockStatus(b.Status) {
+			case BlockStatusOrphaned:
+				return b, ErrBlockOrphaned
+			case BlockStatusConfirmed:
+				return b, nil
+			}
+			if b.ConfirmationProgress >= 1 {
+				return b, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return b, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// findBlock pages through the blocks of a pool, newest first, and returns the block at the given height.
+// It returns nil if the pool has no such block.
+func (c *Client) findBlock(ctx context.Context, id string, blockHeight uint64) (*Block, error) {
+	pageSize := c.clampPageSize(blocksPageSize)
+	for page := 0; ; page++ {
+		res, _, err := c.GetPoolBlocks(withoutCache(ctx), id, map[string]string{
+			"page":     strconv.Itoa(page),
+			"pageSize": strconv.Itoa(pageSize),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range res.Result {
+			if b.BlockHeight == int64(blockHeight) {
+				return b, nil
+			}
+		}
+		n := len(res.Result)
+		if n < pageSize || res.Result[n-1].BlockHeight < int64(blockHeight) || res.Meta == nil || int64(page+1) >= res.PageCount {
+			return nil, nil
+		}
+	}
+}
+
+// poolHashrateThreshold is the relative change of the pool hashrate that WatchPoolStats reports.
+const poolHashrateThreshold = 0.01
+
+// PoolStatsEvent is emitted by WatchPoolStats when the stats of a pool changed or polling them failed.
+type PoolStatsEvent struct {
+	Pool            *PoolInfo
+	ConnectedMiners int32
+	PoolHashrate    int64
+	BlockHeight     int64
+	Time            time.Time
+	// Err is set if the pool could not be fetched, the other fields are zero then.
+	Err error
+}
+
+// WatchPoolStats polls a pool every interval and emits an event whenever the number of connected miners
+// or the network block height changed, or the pool hashrate changed by more than 1% since the last event.
+// The first event holds the stats at the time of the first poll. Failed polls emit an event with Err set
+// and polling continues.
+// Events are coalesced: if the receiver doesn't keep up, a pending event is replaced by a newer one,
+// so only the latest state is delivered. Errors are coalesced on their own and don't replace a pending
+// change, which is delivered first. The channel is closed when ctx is canceled.
+func (c *Client) WatchPoolStats(ctx context.Context, id string, interval time.Duration) (<-chan PoolStatsEvent, error) {
+	if interval <= 0 {
+		return nil, errors.New("miningcore: poll interval must be greater than zero")
+	}
+	ch := make(chan PoolStatsEvent)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// changes and errors are coalesced separately, so a failed poll never drops a pending change
+		var last, pendingState, pendingErr *PoolStatsEvent
+		poll := func() {
+			ev := c.pollPoolStats(ctx, id)
+			if ev.Err != nil {
+				if ctx.Err() == nil {
+					pendingErr = &ev
+				}
+				return
+			}
+			if last == nil || poolStatsChanged(last, &ev) {
+				last = &ev
+				pendingState = &ev
+			}
+		}
+		poll()
+		for {
+			var (
+				out  chan<- PoolStatsEvent
+				next PoolStatsEvent
+			)
+			switch {
+			case pendingState != nil:
+				out, next = ch, *pendingState
+			case pendingErr != nil:
+				out, next = ch, *pendingErr
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- next:
+				if pendingState != nil {
+					pendingState = nil
+				} else {
+					pendingErr = nil
+				}
+			case <-ticker.C:
+				poll()
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// pollPoolStats fetches a pool and returns its stats as event.
+func (c *Client) pollPoolStats(ctx context.Context, id string) PoolStatsEvent {
+	pool, _, err := c.GetPool(withoutCache(ctx), id)
+	if err != nil {
+		return PoolStatsEvent{Time: time.Now(), Err: err}
+	}
+	ev := PoolStatsEvent{Pool: pool, Time: time.Now()}
+	if pool.PoolStats != nil {
+		ev.ConnectedMiners = pool.PoolStats.ConnectedMiners
+		ev.PoolHashrate = pool.PoolStats.PoolHashrate
+	}
+	if pool.NetworkStats != nil {
+		ev.BlockHeight = pool.NetworkStats.BlockHeight
+	}
+	return ev
+}
+
+// poolStatsChanged reports whether curr differs from prev enough to be reported.
+func poolStatsChanged(prev, curr *PoolStatsEvent) bool {
+	if prev.ConnectedMiners != curr.ConnectedMiners || prev.BlockHeight != curr.BlockHeight {
+		return true
+	}
+	if prev.PoolHashrate == 0 {
+		return curr.PoolHashrate != 0
+	}
+	delta := float64(curr.PoolHashrate-prev.PoolHashrate) / float64(prev.PoolHashrate)
+	return delta > poolHashrateThreshold || delta < -poolHashrateThreshold
+}
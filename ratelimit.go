@@ -0,0 +1,56 @@
+package miningcore
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitInfo holds the rate limit state reported by the server in the X-RateLimit-* headers.
+// Fields are zero if the server did not send the corresponding header.
+type RateLimitInfo struct {
+	Limit     int
+	Remaining int
+	// Reset is the time at which the limit resets.
+	Reset time.Time
+}
+
+// epochThreshold separates reset values given as unix timestamps from ones given in seconds from now.
+const epochThreshold = 1e9
+
+// parseRateLimitInfo reads the rate limit headers of a response received at now.
+// X-RateLimit-Reset is accepted as unix timestamp, as number of seconds or as Go duration like "30s".
+func parseRateLimitInfo(h http.Header, now time.Time) RateLimitInfo {
+	var info RateLimitInfo
+	if v, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		info.Limit = v
+	}
+	if v, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		info.Remaining = v
+	}
+	reset := h.Get("X-RateLimit-Reset")
+	if v, err := strconv.ParseFloat(reset, 64); err == nil {
+		if v >= epochThreshold {
+			info.Reset = time.Unix(int64(v), 0)
+		} else {
+			info.Reset = now.Add(time.Duration(v * float64(time.Second)))
+		}
+	} else if d, err := time.ParseDuration(reset); err == nil {
+		info.Reset = now.Add(d)
+	}
+	return info
+}
+
+// RateLimit returns the rate limit state reported with the most recent response.
+func (c *Client) RateLimit() RateLimitInfo {
+	c.rateLimitMu.Lock()
+	defer c.rateLimitMu.Unlock()
+	return c.rateLimit
+}
+
+func (c *Client) updateRateLimit(h http.Header) {
+	info := parseRateLimitInfo(h, time.Now())
+	c.rateLimitMu.Lock()
+	c.rateLimit = info
+	c.rateLimitMu.Unlock()
+}
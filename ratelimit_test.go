@@ -0,0 +1,55 @@
+package miningcore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimitInfo(t *testing.T) {
+	now := time.Date(2022, 6, 20, 12, 0, 0, 0, time.UTC)
+
+	info := parseRateLimitInfo(http.Header{
+		"X-Ratelimit-Limit":     {"100"},
+		"X-Ratelimit-Remaining": {"42"},
+		"X-Ratelimit-Reset":     {"1655733600"},
+	}, now)
+	assert.Equal(t, 100, info.Limit)
+	assert.Equal(t, 42, info.Remaining)
+	assert.True(t, time.Unix(1655733600, 0).Equal(info.Reset))
+
+	info = parseRateLimitInfo(http.Header{"X-Ratelimit-Reset": {"30"}}, now)
+	assert.True(t, now.Add(30*time.Second).Equal(info.Reset))
+
+	info = parseRateLimitInfo(http.Header{"X-Ratelimit-Reset": {"1m30s"}}, now)
+	assert.True(t, now.Add(90*time.Second).Equal(info.Reset))
+
+	assert.Equal(t, RateLimitInfo{}, parseRateLimitInfo(http.Header{}, now))
+	assert.Equal(t, RateLimitInfo{}, parseRateLimitInfo(http.Header{"X-Ratelimit-Limit": {"many"}, "X-Ratelimit-Reset": {"soon"}}, now))
+}
+
+func TestClientRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/pools" {
+			w.Header().Set("X-RateLimit-Limit", "10")
+			w.Header().Set("X-RateLimit-Remaining", "9")
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	client := New(srv.URL)
+	assert.Equal(t, RateLimitInfo{}, client.RateLimit())
+
+	_, _, err := client.GetPools(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 10, client.RateLimit().Limit)
+	assert.Equal(t, 9, client.RateLimit().Remaining)
+
+	_, _, err = client.GetPool(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimitInfo{}, client.RateLimit())
+}
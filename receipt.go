@@ -0,0 +1,62 @@
+package miningcore
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PaymentReceipt is a payment prepared for display.
+type PaymentReceipt struct {
+	Coin    string
+	Address string
+	Amount  Amount
+	// Time is the time of the payment, zero if the server sent an invalid time.
+	Time time.Time
+	TxID string
+	// TxURL links the transaction in a block explorer, empty if no link is known.
+	TxURL string
+}
+
+// Receipt returns the receipt of the payment. The transaction URL is the link resolved by the pool if it
+// sent one, otherwise it is built from the ExplorerTxLink template of coin by replacing {0} with the
+// transaction ID. The coin symbol is used if the payment doesn't name its coin.
+func (p Payment) Receipt(coin Coin) PaymentReceipt {
+	r := PaymentReceipt{
+		Coin:    p.Coin,
+		Address: p.Address,
+		Amount:  Amount(p.Amount),
+		TxID:    p.TransactionConfirmationData,
+		TxURL:   p.TransactionInfoLink,
+	}
+	if r.Coin == "" {
+		r.Coin = coin.Symbol
+	}
+	if t, err := time.Parse(time.RFC3339, p.Created); err == nil {
+		r.Time = t
+	}
+	if r.TxURL == "" {
+		r.TxURL = explorerLink(coin.ExplorerTxLink, r.TxID)
+	}
+	return r
+}
+
+// Receipts returns the receipts of the payments, see Payment.Receipt.
+func Receipts(payments []*Payment, coin Coin) []PaymentReceipt {
+	receipts := make([]PaymentReceipt, 0, len(payments))
+	for _, p := range payments {
+		if p != nil {
+			receipts = append(receipts, p.Receipt(coin))
+		}
+	}
+	return receipts
+}
+
+// explorerLink substitutes id into the miningcore explorer link template tmpl.
+// It returns an empty string if the template or the id is empty or the template has no placeholder.
+func explorerLink(tmpl, id string) string {
+	if tmpl == "" || id == "" || !strings.Contains(tmpl, "{0}") {
+		return ""
+	}
+	return strings.ReplaceAll(tmpl, "{0}", url.PathEscape(id))
+}
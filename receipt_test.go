@@ -0,0 +1,58 @@
+package miningcore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentReceipt(t *testing.T) {
+	coin := Coin{Symbol: "ETH", ExplorerTxLink: "https://etherscan.io/tx/{0}"}
+
+	r := Payment{
+		Address:                     testMiner,
+		Amount:                      0.5,
+		TransactionConfirmationData: "0xabc",
+		Created:                     "2022-06-20T08:00:00Z",
+	}.Receipt(coin)
+	assert.Equal(t, "ETH", r.Coin)
+	assert.Equal(t, testMiner, r.Address)
+	assert.Equal(t, Amount(0.5), r.Amount)
+	assert.Equal(t, "0xabc", r.TxID)
+	assert.Equal(t, "https://etherscan.io/tx/0xabc", r.TxURL)
+	assert.Equal(t, time.Date(2022, 6, 20, 8, 0, 0, 0, time.UTC), r.Time.UTC())
+
+	// links resolved by the pool take precedence
+	r = Payment{Coin: "ETC", TransactionConfirmationData: "0xabc", TransactionInfoLink: "https://blockscout.com/etc/tx/0xabc"}.Receipt(coin)
+	assert.Equal(t, "ETC", r.Coin)
+	assert.Equal(t, "https://blockscout.com/etc/tx/0xabc", r.TxURL)
+	assert.True(t, r.Time.IsZero())
+
+	tests := []struct {
+		tmpl, id, want string
+	}{
+		{"https://explorer.example.com/tx/{0}", "a b/c", "https://explorer.example.com/tx/a%20b%2Fc"},
+		{"https://explorer.example.com/tx/{0}?ref={0}", "ff", "https://explorer.example.com/tx/ff?ref=ff"},
+		{"https://explorer.example.com/tx/", "ff", ""},
+		{"", "ff", ""},
+		{"https://explorer.example.com/tx/{0}", "", ""},
+	}
+	for _, tt := range tests {
+		r = Payment{TransactionConfirmationData: tt.id}.Receipt(Coin{ExplorerTxLink: tt.tmpl})
+		assert.Equal(t, tt.want, r.TxURL, tt.tmpl)
+	}
+}
+
+func TestReceipts(t *testing.T) {
+	res, _, err := newClient().GetMinerPayments(context.Background(), "eth", testMiner)
+	assert.NoError(t, err)
+
+	receipts := Receipts(append(res.Result, nil), Coin{Symbol: "ETH"})
+	assert.Equal(t, 2, len(receipts))
+	assert.Equal(t, "https://etherscan.io/tx/0x02", receipts[0].TxURL)
+	assert.Equal(t, Amount(0.15), receipts[1].Amount)
+	assert.Equal(t, time.Date(2022, 6, 19, 8, 0, 0, 0, time.UTC), receipts[1].Time.UTC())
+	assert.Empty(t, Receipts(nil, Coin{}))
+}
@@ -0,0 +1,201 @@
+package miningcore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	// replayURL is the base URL of replay clients, requests never leave the process.
+	replayURL = "http://replay.miningcore"
+	// maxRecordingSize is the maximum size of a recording line read by NewReplayClient,
+	// large enough for the biggest pages of results.
+	maxRecordingSize = 256 << 20
+)
+
+// Recording is a recorded request and its response, written as one JSON line by WithRecorder.
+type Recording struct {
+	Method   string            `json:"method"`
+	Endpoint string            `json:"endpoint"`
+	Params   map[string]string `json:"params,omitempty"`
+	Status   int               `json:"status"`
+	Header   http.Header       `json:"header,omitempty"`
+	Body     string            `json:"body"`
+}
+
+// WithRecorder writes every request and its response to w as a JSON encoded Recording per line,
+// for example to capture a session against a live pool and replay it with NewReplayClient.
+// Responses are passed on unchanged. Request headers are not recorded, neither are Set-Cookie headers
+// of responses and event streams, which never end.
+func WithRecorder(w io.Writer) ClientOpts {
+	return func(c *Client) {
+		c.recorder = w
+	}
+}
+
+// recordingTransport records the round trips of next to w.
+type recordingTransport struct {
+	next     http.RoundTripper
+	basePath string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// newRecordingTransport records the requests sent with next to w,
+// with endpoints relative to the path of baseURL.
+func newRecordingTransport(next http.RoundTripper, w io.Writer, baseURL string) *recordingTransport {
+	t := &recordingTransport{next: next, w: w}
+	if u, err := url.Parse(baseURL); err == nil {
+		t.basePath = strings.TrimSuffix(u.EscapedPath(), "/")
+	}
+	return t
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt == "text/event-stream" {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	// the recorder must not consume the body of the live response
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	rec := Recording{
+		Method:   req.Method,
+		Endpoint: "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.EscapedPath(), t.basePath), "/"),
+		Params:   queryParams(req.URL.Query()),
+		Status:   resp.StatusCode,
+		Header:   resp.Header.Clone(),
+		Body:     string(body),
+	}
+	rec.Header.Del("Set-Cookie")
+	line, err := json.Marshal(&rec)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.w.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("miningcore: failed to record response: %w", err)
+	}
+	return resp, nil
+}
+
+// queryParams returns the first value of every query parameter.
+func queryParams(q url.Values) map[string]string {
+	if len(q) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(q))
+	for k := range q {
+		params[k] = q.Get(k)
+	}
+	return params
+}
+
+// NewReplayClient returns a client that answers requests with the responses recorded with WithRecorder
+// and read from r, without any network access. Requests are matched by method, endpoint and params.
+// Identical requests are answered with their recordings in order, the last one is repeated once all were used.
+// Requests without a recording fail with an error. Recordings must not be larger than 256 MiB each.
+func NewReplayClient(r io.Reader, opts ...ClientOpts) (*Client, error) {
+	t := &replayTransport{recordings: make(map[string][]*Recording)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxRecordingSize)
+	n := 1
+	for ; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec Recording
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("miningcore: invalid recording in line %d: %w", n, err)
+		}
+		key := replayKey(rec.Method, rec.Endpoint, rec.Params)
+		t.recordings[key] = append(t.recordings[key], &rec)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("miningcore: recording in line %d exceeds %d bytes: %w", n, maxRecordingSize, err)
+		}
+		return nil, fmt.Errorf("miningcore: failed to read recording in line %d: %w", n, err)
+	}
+
+	return New(replayURL, append([]ClientOpts{withReplay(t)}, opts...)...), nil
+}
+
+// withReplay answers requests with t instead of sending them.
+func withReplay(t http.RoundTripper) ClientOpts {
+	return func(c *Client) {
+		c.replay = t
+	}
+}
+
+// replayTransport answers requests with recorded responses.
+type replayTransport struct {
+	mu         sync.Mutex
+	recordings map[string][]*Recording
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := replayKey(req.Method, req.URL.EscapedPath(), queryParams(req.URL.Query()))
+
+	t.mu.Lock()
+	recs := t.recordings[key]
+	if len(recs) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("miningcore: no recorded response for %s %s", req.Method, req.URL.RequestURI())
+	}
+	rec := recs[0]
+	if len(recs) > 1 {
+		t.recordings[key] = recs[1:]
+	}
+	t.mu.Unlock()
+
+	header := rec.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// replayKey identifies a request among the recordings.
+func replayKey(method, endpoint string, params map[string]string) string {
+	q := url.Values{}
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	return method + " " + endpoint + "?" + q.Encode()
+}
@@ -0,0 +1,123 @@
+package miningcore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	params := map[string]string{"page": "0", "pageSize": "15"}
+
+	var buf bytes.Buffer
+	client := New(testServer.URL, WithRecorder(&buf))
+	pools, code, err := client.GetPools(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, pools)
+	blocks, _, err := client.GetPoolBlocks(ctx, "eth", params)
+	assert.NoError(t, err)
+	_, code, err = client.GetPool(ctx, "unknown")
+	assert.Error(t, err)
+
+	var recs []Recording
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		var rec Recording
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		recs = append(recs, rec)
+	}
+	assert.Equal(t, 3, len(recs))
+	assert.Equal(t, http.MethodGet, recs[0].Method)
+	assert.Equal(t, "/api/pools", recs[0].Endpoint)
+	assert.Equal(t, http.StatusOK, recs[0].Status)
+	assert.Equal(t, "/api/v2/pools/eth/blocks", recs[1].Endpoint)
+	assert.Equal(t, params, recs[1].Params)
+	assert.Equal(t, code, recs[2].Status)
+
+	// the recorded session is answered offline
+	replay, err := NewReplayClient(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	replayedPools, code, err := replay.GetPools(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, pools, replayedPools)
+	replayedBlocks, _, err := replay.GetPoolBlocks(ctx, "eth", params)
+	assert.NoError(t, err)
+	assert.Equal(t, blocks, replayedBlocks)
+	_, _, err = replay.GetPool(ctx, "unknown")
+	assert.True(t, IsPoolNotFound(err))
+
+	// requests that were not recorded fail
+	_, _, err = replay.GetPoolBlocks(ctx, "eth", map[string]string{"page": "1", "pageSize": "15"})
+	assert.ErrorContains(t, err, "no recorded response")
+}
+
+func TestRecorderBasePath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"pool": {"id": "eth"}}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	_, _, err := New(srv.URL+"/miningcore/", WithRecorder(&buf)).GetPool(context.Background(), "eth")
+	assert.NoError(t, err)
+
+	var rec Recording
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, "/api/pools/eth", rec.Endpoint)
+	assert.Equal(t, `{"pool": {"id": "eth"}}`, rec.Body)
+}
+
+func TestReplayOrder(t *testing.T) {
+	recs := strings.Join([]string{
+		`{"method": "GET", "endpoint": "/api/pools/eth", "status": 200, "body": "{\"pool\": {\"id\": \"eth\", \"poolStats\": {\"connectedMiners\": 1}}}"}`,
+		``,
+		`{"method": "GET", "endpoint": "/api/pools/eth", "status": 200, "body": "{\"pool\": {\"id\": \"eth\", \"poolStats\": {\"connectedMiners\": 2}}}"}`,
+	}, "\n")
+	client, err := NewReplayClient(strings.NewReader(recs))
+	assert.NoError(t, err)
+
+	// identical requests get their responses in recorded order, the last one is repeated
+	for _, want := range []int32{1, 2, 2} {
+		pool, _, err := client.GetPool(context.Background(), "eth")
+		assert.NoError(t, err)
+		assert.Equal(t, want, pool.PoolStats.ConnectedMiners)
+	}
+
+	_, err = NewReplayClient(strings.NewReader("not json"))
+	assert.ErrorContains(t, err, "line 1")
+
+	readErr := errors.New("read failed")
+	_, err = NewReplayClient(io.MultiReader(strings.NewReader(recs+"\n"), iotest.ErrReader(readErr)))
+	assert.ErrorIs(t, err, readErr)
+	assert.ErrorContains(t, err, "line 4")
+}
+
+func TestReplayLargeRecording(t *testing.T) {
+	// responses far larger than an event stream line can be replayed
+	name := strings.Repeat("x", 16<<20)
+	line, err := json.Marshal(&Recording{
+		Method:   http.MethodGet,
+		Endpoint: "/api/pools/eth",
+		Status:   http.StatusOK,
+		Body:     `{"pool": {"id": "eth", "coin": {"name": "` + name + `"}}}`,
+	})
+	assert.NoError(t, err)
+	client, err := NewReplayClient(bytes.NewReader(line))
+	assert.NoError(t, err)
+	pool, _, err := client.GetPool(context.Background(), "eth")
+	assert.NoError(t, err)
+	assert.Equal(t, len(name), len(pool.Coin.Name))
+}
@@ -0,0 +1,57 @@
+package miningcore
+
+import (
+	"errors"
+	"net/http"
+)
+
+// RedirectPolicy controls how the client handles redirects.
+type RedirectPolicy int
+
+const (
+	// RedirectDefault follows up to 10 redirects using the net/http rules.
+	// net/http only drops credentials if the redirect leaves the domain, different ports on the same host keep them.
+	RedirectDefault RedirectPolicy = iota
+	// RedirectNone does not follow redirects, the redirect response is returned as APIError.
+	RedirectNone
+	// RedirectSameHostOnly follows up to 10 redirects, but only sends credentials like the auth token
+	// to the exact host and port of the original request.
+	RedirectSameHostOnly
+)
+
+// maxRedirects is the number of redirects followed before giving up, the same as net/http.
+const maxRedirects = 10
+
+// sensitiveHeaders are removed from redirected requests to other hosts.
+var sensitiveHeaders = []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"}
+
+// WithRedirectPolicy sets the redirect policy of the client.
+func WithRedirectPolicy(policy RedirectPolicy) ClientOpts {
+	return func(c *Client) {
+		c.redirectPolicy = policy
+	}
+}
+
+// checkRedirect returns the http.Client CheckRedirect func of the policy.
+func (p RedirectPolicy) checkRedirect() func(req *http.Request, via []*http.Request) error {
+	switch p {
+	case RedirectNone:
+		return func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	case RedirectSameHostOnly:
+		return func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("miningcore: stopped after 10 redirects")
+			}
+			if req.URL.Host != via[0].URL.Host {
+				for _, h := range sensitiveHeaders {
+					req.Header.Del(h)
+				}
+			}
+			return nil
+		}
+	default:
+		return nil
+	}
+}
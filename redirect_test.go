@@ -0,0 +1,62 @@
+package miningcore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectPolicy(t *testing.T) {
+	var auth string
+	var reached bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"pools": []}`))
+	}))
+	defer target.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer origin.Close()
+
+	// net/http keeps the header since only the port differs
+	_, _, err := New(origin.URL, WithAuthToken("secret")).GetPools(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer secret", auth)
+
+	reached, auth = false, ""
+	_, code, err := New(origin.URL, WithAuthToken("secret"), WithRedirectPolicy(RedirectSameHostOnly)).GetPools(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, reached)
+	assert.Empty(t, auth)
+
+	reached = false
+	_, code, err = New(origin.URL, WithAuthToken("secret"), WithRedirectPolicy(RedirectNone)).GetPools(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusFound, code)
+	assert.False(t, reached)
+}
+
+func TestRedirectSameHost(t *testing.T) {
+	var auth string
+	handler := http.NewServeMux()
+	handler.HandleFunc("/old/api/pools", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/api/pools", http.StatusMovedPermanently)
+	})
+	handler.HandleFunc("/api/pools", func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"pools": []}`))
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client := New(srv.URL, WithAuthToken("secret"), WithRedirectPolicy(RedirectSameHostOnly))
+	_, err := client.doRequest(context.Background(), "/old/api/pools", http.MethodGet, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer secret", auth)
+}
@@ -0,0 +1,202 @@
+package miningcore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// maxBackoffShift caps the exponent of the backoff so it can't overflow.
+const maxBackoffShift = 30
+
+// BackoffStrategy returns the time to wait before the retry following the given attempt, starting at 1,
+// where base is the backoff passed to WithRetry.
+type BackoffStrategy func(attempt int, base time.Duration) time.Duration
+
+// randFloat64 is the source of randomness of the jittered strategies, replaced in tests.
+var randFloat64 = rand.Float64 // #nosec G404
+
+// exponentialBackoff returns base doubled for every attempt after the first.
+func exponentialBackoff(attempt int, base time.Duration) time.Duration {
+	shift := attempt - 1
+	if shift > maxBackoffShift {
+		shift = maxBackoffShift
+	}
+	if shift < 0 {
+		shift = 0
+	}
+	return base << shift
+}
+
+// BackoffNoJitter waits exactly base, doubled for every further attempt.
+func BackoffNoJitter(attempt int, base time.Duration) time.Duration {
+	return exponentialBackoff(attempt, base)
+}
+
+// BackoffFullJitter waits a random time between 0 and the exponential backoff.
+// This spreads retries of many clients the most and is the default.
+func BackoffFullJitter(attempt int, base time.Duration) time.Duration {
+	return time.Duration(randFloat64() * float64(exponentialBackoff(attempt, base)))
+}
+
+// BackoffEqualJitter waits half of the exponential backoff plus a random time up to the other half.
+func BackoffEqualJitter(attempt int, base time.Duration) time.Duration {
+	half := exponentialBackoff(attempt, base) / 2
+	return half + time.Duration(randFloat64()*float64(half))
+}
+
+type retryConfig struct {
+	attempts    int
+	backoff     time.Duration
+	maxDuration time.Duration
+	strategy    BackoffStrategy
+
+	attemptTimeout time.Duration
+	predicate      func(resp *http.Response, err error) bool
+}
+
+// WithRetry retries idempotent requests (GET and HEAD) that failed with a transport error or a 5xx status.
+// A request is sent at most attempts times. The wait between attempts grows exponentially from backoff,
+// with jitter according to the backoff strategy, see WithBackoffStrategy. Responses with a Retry-After
+// header, like 503 responses of paused pools, are waited for at least as long as the server asks.
+func WithRetry(attempts int, backoff time.Duration) ClientOpts {
+	return func(c *Client) {
+		c.retry.attempts = attempts
+		c.retry.backoff = backoff
+	}
+}
+
+// WithBackoffStrategy sets how the wait between retries is computed.
+// Use one of BackoffFullJitter, BackoffEqualJitter and BackoffNoJitter or a custom func.
+func WithBackoffStrategy(strategy BackoffStrategy) ClientOpts {
+	return func(c *Client) {
+		c.retry.strategy = strategy
+	}
+}
+
+// WithMaxRetryDuration stops retrying once d has passed since the first attempt, including the time spent
+// waiting between attempts, even if attempts are left. A retry is not started if its wait would end after
+// the budget. The context deadline of the call still applies, whichever is earlier wins.
+func WithMaxRetryDuration(d time.Duration) ClientOpts {
+	return func(c *Client) {
+		c.retry.maxDuration = d
+	}
+}
+
+// WithAttemptTimeout limits the time of every single attempt of a request to d, independent of the context
+// of the call, so an attempt that hangs doesn't use up the whole deadline. An attempt that times out is
+// retried like a transport error. Time spent waiting for a free request slot doesn't count towards d.
+func WithAttemptTimeout(d time.Duration) ClientOpts {
+	return func(c *Client) {
+		c.retry.attemptTimeout = d
+	}
+}
+
+// WithRetryPredicate sets the func that decides whether a failed attempt, one with a transport error
+// or a status of 400 or above, is retried. It replaces the default of retrying transport errors and
+// 5xx statuses, for example to also retry 429 or never retry 501. resp is nil if err is set.
+// The body of resp can be read by the predicate without affecting the result.
+//
+// The predicate is asked for requests of every method: returning true retries POST requests as well,
+// so check resp.Request.Method to keep them excluded. On transport errors, where resp is nil,
+// only GET and HEAD requests are retried, since it is unknown whether the server processed the request.
+// Requests that must never be applied twice, like admin balance changes, are not retried regardless.
+// Retries are only made if enabled with WithRetry.
+func WithRetryPredicate(fn func(resp *http.Response, err error) bool) ClientOpts {
+	return func(c *Client) {
+		c.retry.predicate = fn
+	}
+}
+
+type noRetryKey struct{}
+
+// withoutRetry returns a context whose requests are never retried, for requests that must not be applied twice.
+func withoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// send sends req, retrying it according to the retry configuration.
+// If stream is set, the body of a 200 response is left unread for the caller to close, see roundTrip.
+func (c *Client) send(ctx context.Context, req *http.Request, stream bool) (*http.Response, []byte, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, nil, err
+			}
+			req.Body = body
+		}
+		resp, body, err := c.attempt(ctx, req, stream)
+		if attempt >= c.retry.attempts || !c.shouldRetry(ctx, req, resp, body, err) {
+			return resp, body, err
+		}
+
+		wait := c.retryBackoff(attempt)
+		if resp != nil {
+			// the server asked to back off for longer
+			if ra := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ra > wait {
+				wait = ra
+			}
+		}
+		if c.retry.maxDuration > 0 && time.Since(start)+wait > c.retry.maxDuration {
+			return resp, body, err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, body, err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends req once, limited by the attempt timeout if set.
+// The request slot is acquired first, so waiting for it doesn't count towards the attempt timeout.
+func (c *Client) attempt(ctx context.Context, req *http.Request, stream bool) (*http.Response, []byte, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.retry.attemptTimeout <= 0 {
+		return c.roundTrip(req, stream, release)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, c.retry.attemptTimeout)
+	// the attempt context is canceled once the body was read, for streamed bodies once they are closed
+	return c.roundTrip(req.WithContext(attemptCtx), stream, func() {
+		release()
+		cancel()
+	})
+}
+
+// shouldRetry reports whether a request that resulted in resp with body or err should be sent again.
+func (c *Client) shouldRetry(ctx context.Context, req *http.Request, resp *http.Response, body []byte, err error) bool {
+	if ctx.Err() != nil || ctx.Value(noRetryKey{}) != nil {
+		return false
+	}
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	if c.retry.predicate == nil {
+		return idempotent && (err != nil || resp.StatusCode >= 500)
+	}
+	if err != nil {
+		return idempotent && c.retry.predicate(nil, err)
+	}
+	if resp.StatusCode < 400 {
+		return false
+	}
+	// the body was read already, give the predicate its own copy
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return c.retry.predicate(resp, nil)
+}
+
+// retryBackoff returns the time to wait before the retry following the given attempt.
+func (c *Client) retryBackoff(attempt int) time.Duration {
+	if c.retry.strategy == nil {
+		return BackoffFullJitter(attempt, c.retry.backoff)
+	}
+	return c.retry.strategy(attempt, c.retry.backoff)
+}
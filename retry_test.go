@@ -0,0 +1,285 @@
+package miningcore
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyServer fails the first failures requests with a 503 and counts all requests.
+func flakyServer(failures int32, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(requests, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"pools": []}`))
+	}))
+}
+
+func TestRetry(t *testing.T) {
+	var requests int32
+	srv := flakyServer(2, &requests)
+	defer srv.Close()
+
+	_, code, err := New(srv.URL, WithRetry(3, time.Millisecond)).GetPools(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int32(3), requests)
+
+	atomic.StoreInt32(&requests, 0)
+	_, code, err = New(srv.URL, WithRetry(2, time.Millisecond)).GetPools(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, int32(2), requests)
+
+	// requests are not retried by default
+	atomic.StoreInt32(&requests, 0)
+	_, _, err = New(srv.URL).GetPools(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), requests)
+}
+
+func TestRetryAfter(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"pools": []}`))
+	}))
+	defer srv.Close()
+
+	// the retry waits as long as the server asks, not just the backoff
+	start := time.Now()
+	_, _, err := New(srv.URL, WithRetry(2, time.Millisecond)).GetPools(context.Background())
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// a Retry-After beyond the retry budget is not waited for
+	atomic.StoreInt32(&requests, 0)
+	start = time.Now()
+	_, _, err = New(srv.URL, WithRetry(2, time.Millisecond), WithMaxRetryDuration(100*time.Millisecond)).GetPools(context.Background())
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestRetrySkipsPost(t *testing.T) {
+	var requests int32
+	srv := flakyServer(5, &requests)
+	defer srv.Close()
+
+	_, err := New(srv.URL, WithRetry(3, time.Millisecond)).UnmarshalPostMinerSettings(context.Background(), "eth", testMiner, &MinerSettingsUpdateReq{}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), requests)
+}
+
+func TestMaxRetryDuration(t *testing.T) {
+	var requests int32
+	srv := flakyServer(1000, &requests)
+	defer srv.Close()
+
+	client := New(srv.URL, WithRetry(100, 10*time.Millisecond), WithBackoffStrategy(BackoffNoJitter), WithMaxRetryDuration(100*time.Millisecond))
+	start := time.Now()
+	_, code, err := client.GetPools(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+	// 10ms, 20ms and 40ms fit into the budget, the 80ms wait of the next retry does not
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+}
+
+func TestRetryContextDeadline(t *testing.T) {
+	var requests int32
+	srv := flakyServer(1000, &requests)
+	defer srv.Close()
+
+	client := New(srv.URL, WithRetry(100, 10*time.Millisecond), WithMaxRetryDuration(time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err := client.GetPools(ctx)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestBackoffStrategies(t *testing.T) {
+	t.Cleanup(func() {
+		randFloat64 = rand.Float64
+	})
+	base := 100 * time.Millisecond
+
+	assert.Equal(t, 100*time.Millisecond, BackoffNoJitter(1, base))
+	assert.Equal(t, 200*time.Millisecond, BackoffNoJitter(2, base))
+	assert.Equal(t, 800*time.Millisecond, BackoffNoJitter(4, base))
+	assert.Equal(t, base<<maxBackoffShift, BackoffNoJitter(1000, base))
+
+	randFloat64 = func() float64 { return 0 }
+	assert.Equal(t, time.Duration(0), BackoffFullJitter(3, base))
+	assert.Equal(t, 200*time.Millisecond, BackoffEqualJitter(3, base))
+
+	randFloat64 = func() float64 { return 0.5 }
+	assert.Equal(t, 200*time.Millisecond, BackoffFullJitter(3, base))
+	assert.Equal(t, 300*time.Millisecond, BackoffEqualJitter(3, base))
+
+	randFloat64 = func() float64 { return 0.999999 }
+	assert.Less(t, BackoffFullJitter(3, base), 400*time.Millisecond)
+	assert.Less(t, BackoffEqualJitter(3, base), 400*time.Millisecond)
+	assert.Greater(t, BackoffEqualJitter(3, base), 399*time.Millisecond)
+
+	// full jitter is the default
+	randFloat64 = func() float64 { return 0.25 }
+	assert.Equal(t, 50*time.Millisecond, New("http://localhost", WithRetry(3, base)).retryBackoff(2))
+
+	custom := New("http://localhost", WithRetry(3, base), WithBackoffStrategy(func(attempt int, base time.Duration) time.Duration {
+		return time.Duration(attempt) * base
+	}))
+	assert.Equal(t, 300*time.Millisecond, custom.retryBackoff(3))
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte(`{"pools": [{"id": "eth"}]}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := New(srv.URL, WithRetry(2, time.Millisecond), WithAttemptTimeout(50*time.Millisecond))
+	start := time.Now()
+	pools, code, err := client.GetPools(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, len(pools))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Less(t, time.Since(start), time.Second)
+
+	// without retries the timed out attempt fails the request
+	atomic.StoreInt32(&requests, 0)
+	_, _, err = New(srv.URL, WithAttemptTimeout(50*time.Millisecond)).GetPools(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NoError(t, ctx.Err())
+}
+
+func TestAttemptTimeoutExcludesSlotWait(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(60 * time.Millisecond)
+		w.Write([]byte(`{"pools": []}`))
+	}))
+	defer srv.Close()
+
+	// requests queued for the only slot get the full attempt timeout once they are sent
+	client := New(srv.URL, WithMaxConcurrentRequests(1), WithAttemptTimeout(100*time.Millisecond))
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, _, err := client.GetPools(context.Background())
+			errs <- err
+		}()
+	}
+	for i := 0; i < cap(errs); i++ {
+		assert.NoError(t, <-errs)
+	}
+}
+
+func TestRetryPredicate(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("slow down"))
+		case 2:
+			w.WriteHeader(http.StatusNotImplemented)
+			w.Write([]byte("not implemented"))
+		default:
+			w.Write([]byte(`{"pools": []}`))
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	var bodies []string
+	retry429 := func(resp *http.Response, err error) bool {
+		if err != nil {
+			return true
+		}
+		b, _ := io.ReadAll(resp.Body)
+		bodies = append(bodies, string(b))
+		return resp.StatusCode == http.StatusTooManyRequests
+	}
+	client := New(srv.URL, WithRetry(5, time.Millisecond), WithRetryPredicate(retry429))
+	_, code, err := client.GetPools(ctx)
+	// the 429 is retried, the 501 is not
+	assert.Equal(t, http.StatusNotImplemented, code)
+	assert.EqualError(t, err, "not implemented")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, []string{"slow down", "not implemented"}, bodies)
+
+	// the default retries 501
+	atomic.StoreInt32(&requests, 1)
+	_, code, err = New(srv.URL, WithRetry(5, time.Millisecond)).GetPools(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestRetryPredicatePost(t *testing.T) {
+	var requests int32
+	srv := flakyServer(1, &requests)
+	defer srv.Close()
+	ctx := context.Background()
+	settings := &MinerSettingsUpdateReq{}
+
+	// POST requests are only retried if the predicate opts in
+	onlyGet := func(resp *http.Response, err error) bool {
+		return resp.Request.Method == http.MethodGet && resp.StatusCode >= 500
+	}
+	_, err := New(srv.URL, WithRetry(3, time.Millisecond), WithRetryPredicate(onlyGet)).UnmarshalPostMinerSettings(ctx, "eth", testMiner, settings, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	atomic.StoreInt32(&requests, 0)
+	always := func(*http.Response, error) bool { return true }
+	client := New(srv.URL, WithRetry(3, time.Millisecond), WithRetryPredicate(always))
+	_, err = client.UnmarshalPostMinerSettings(ctx, "eth", testMiner, settings, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// balance changes are never retried
+	atomic.StoreInt32(&requests, 0)
+	_, err = client.AddBalance(ctx, "eth", &BalanceAdjustment{Address: testMiner, Amount: 1})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// POST requests that failed without a response are not retried
+	var calls int32
+	countAll := func(*http.Response, error) bool { atomic.AddInt32(&calls, 1); return true }
+	_, err = New("http://127.0.0.1:1", WithRetry(3, time.Millisecond), WithRetryPredicate(countAll)).UnmarshalPostMinerSettings(ctx, "eth", testMiner, settings, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	_, err = New("http://127.0.0.1:1", WithRetry(3, time.Millisecond), WithRetryPredicate(countAll)).UnmarshalPools(ctx, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
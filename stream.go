@@ -0,0 +1,181 @@
+package miningcore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDrainBytes is the maximum number of unread bytes discarded before a response body is closed.
+const maxDrainBytes = 64 << 10
+
+// drainAndClose reads what is left of body, up to maxDrainBytes, and closes it.
+// The transport only reuses a connection once the previous body was read to the end,
+// so a stream that stops early would otherwise cost a new connection for the next request.
+// Larger remainders are cheaper to drop with the connection than to read.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.CopyN(ioutil.Discard, body, maxDrainBytes)
+	_ = body.Close()
+}
+
+// StreamPoolBlocks calls fn for every block of a page of blocks found by a pool, as they are decoded
+// from the response. Iteration stops at the first error returned by fn, which is then returned.
+// The response is decoded with encoding/json regardless of the configured JSON decoder.
+// This endpoint implements pagination using the `page` and `pageSize` parameters.
+func (c *Client) StreamPoolBlocks(ctx context.Context, id string, fn func(*Block) error, params ...map[string]string) error {
+	e, err := endpoint("/api/v2/pools/%s/blocks", id)
+	if err != nil {
+		return err
+	}
+	return streamPage(ctx, c, e, fn, nil, params...)
+}
+
+// StreamPoolPayments calls fn for every payment of a page of payments made by a pool.
+// See StreamPoolBlocks for the behavior.
+func (c *Client) StreamPoolPayments(ctx context.Context, id string, fn func(*Payment) error, params ...map[string]string) error {
+	e, err := endpoint("/api/v2/pools/%s/payments", id)
+	if err != nil {
+		return err
+	}
+	return streamPage(ctx, c, e, fn, nil, params...)
+}
+
+// StreamMinerPayments calls fn for every payment of a page of payments of a miner.
+// See StreamPoolBlocks for the behavior.
+func (c *Client) StreamMinerPayments(ctx context.Context, id, addr string, fn func(*Payment) error, params ...map[string]string) error {
+	return c.streamMinerPayments(ctx, id, addr, fn, nil, params...)
+}
+
+// streamMinerPayments is StreamMinerPayments, storing the pagination fields of the response in meta if set.
+func (c *Client) streamMinerPayments(ctx context.Context, id, addr string, fn func(*Payment) error, meta *Meta, params ...map[string]string) error {
+	e, err := endpoint("/api/v2/pools/%s/miners/%s/payments", id, c.address(addr))
+	if err != nil {
+		return err
+	}
+	return streamPage(ctx, c, e, fn, meta, params...)
+}
+
+// streamPage requests a paginated endpoint and calls fn for each element of its `result` array.
+// The page count of the response is stored in meta if set.
+func streamPage[T any](ctx context.Context, c *Client, endpoint string, fn func(T) error, meta *Meta, params ...map[string]string) error {
+	p, err := c.pageParams(ctx, params...)
+	if err != nil {
+		return err
+	}
+	return c.stream(ctx, endpoint, func(r io.Reader) error {
+		return decodeResult(endpoint, r, fn, meta)
+	}, p)
+}
+
+// stream performs a GET request and passes the response body to fn.
+// The request is sent like any other, with retries and the attempt timeout, which also covers reading the body.
+// The body is drained and closed once fn returns, even if it did not read it to the end.
+func (c *Client) stream(ctx context.Context, endpoint string, fn func(r io.Reader) error, params ...map[string]string) error {
+	defer c.reportSlow(endpoint, time.Now())
+	req, err := c.newRequest(ctx, endpoint, http.MethodGet, nil, params...)
+	if err != nil {
+		return err
+	}
+	resp, body, err := c.send(ctx, req, true)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError(endpoint, resp, body)
+	}
+	defer drainAndClose(resp.Body)
+	return fn(resp.Body)
+}
+
+// streamBody is the unread body of a streamed response, closing it calls release.
+type streamBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// decodeResult decodes the elements of the `result` array of a JSON object one at a time and passes them to fn.
+// Like unwrapEnvelope, it returns an APIError wrapping ErrUnsuccessfulResponse if the object reports "success": false.
+// The page count, which may follow the result, is stored in meta if set.
+func decodeResult[T any](endpoint string, r io.Reader, fn func(T) error, meta *Meta) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch key, _ := tok.(string); key {
+		case "result":
+		case "success":
+			var success *bool
+			if err := dec.Decode(&success); err != nil {
+				return err
+			}
+			if success != nil && !*success {
+				e := newAPIError(endpoint, http.StatusOK, nil)
+				e.Err = ErrUnsuccessfulResponse
+				return e
+			}
+			continue
+		case "pageCount":
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			if n, err := strconv.ParseInt(strings.Trim(string(raw), `"`), 10, 64); err == nil && meta != nil {
+				meta.PageCount = n
+			}
+			continue
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return err
+		}
+		for dec.More() {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				return err
+			}
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+		// the fields following the result, like the page count, are read as well
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("miningcore: unexpected token %v, expected %v", tok, delim)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package miningcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamPoolBlocks(t *testing.T) {
+	var heights []int64
+	err := newClient().StreamPoolBlocks(context.Background(), "eth", func(b *Block) error {
+		heights = append(heights, b.BlockHeight)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{15000004, 15000003, 15000002, 15000001}, heights)
+
+	heights = nil
+	err = newClient().StreamPoolBlocks(context.Background(), "eth", func(b *Block) error {
+		heights = append(heights, b.BlockHeight)
+		return nil
+	}, map[string]string{"state": "confirmed"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{15000003, 15000001}, heights)
+}
+
+func TestStreamMinerPayments(t *testing.T) {
+	var amounts []float64
+	err := newClient().StreamMinerPayments(context.Background(), "eth", testMiner, func(p *Payment) error {
+		amounts = append(amounts, p.Amount)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.2, 0.15}, amounts)
+
+	err = newClient().StreamMinerPayments(context.Background(), "nope", testMiner, func(p *Payment) error {
+		return nil
+	})
+	assert.True(t, IsMinerNotFound(err))
+}
+
+func TestStreamEarlyReturnReusesConnection(t *testing.T) {
+	var payments strings.Builder
+	payments.WriteString(`{"pageCount": 1, "success": true, "result": [`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			payments.WriteString(",")
+		}
+		fmt.Fprintf(&payments, `{"amount": %d}`, i)
+	}
+	payments.WriteString(`]}`)
+
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payments.String()))
+	}))
+	srv.Config.ConnState = func(c net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	client := New(srv.URL)
+	stop := errors.New("stop")
+	for i := 0; i < 3; i++ {
+		var n int
+		err := client.StreamPoolPayments(context.Background(), "eth", func(p *Payment) error {
+			n++
+			if n == 2 {
+				return stop
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 2, n)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns))
+}
+
+func TestStreamSendsLikeRequests(t *testing.T) {
+	ctx := context.Background()
+	var requests int32
+	srv := flakyServer(2, &requests)
+	defer srv.Close()
+
+	// streams are retried and reported as slow like any other request
+	var slow []string
+	client := New(srv.URL, WithRetry(3, time.Millisecond), WithAttemptTimeout(time.Second), WithMaxConcurrentRequests(1),
+		WithSlowRequestThreshold(0, func(endpoint string, _ time.Duration) {
+			slow = append(slow, endpoint)
+		}))
+	for i := 0; i < 2; i++ {
+		err := client.StreamPoolBlocks(ctx, "eth", func(*Block) error { return nil })
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+	assert.Equal(t, []string{"/api/v2/pools/eth/blocks", "/api/v2/pools/eth/blocks"}, slow)
+
+	unsuccessful := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success": false, "responseMessageType": 1, "result": null}`))
+	}))
+	defer unsuccessful.Close()
+	err := New(unsuccessful.URL).StreamPoolBlocks(ctx, "eth", func(*Block) error { return nil })
+	assert.ErrorIs(t, err, ErrUnsuccessfulResponse)
+
+	// the attempt timeout covers reading the body
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success": true, "result": [`))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer stalled.Close()
+	err = New(stalled.URL, WithAttemptTimeout(50*time.Millisecond)).StreamPoolBlocks(ctx, "eth", func(*Block) error { return nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}